@@ -12,6 +12,7 @@ type Api struct {
 	iACU  domain.IAppConfigUseCase
 	iDPH  domain.IDataPointHandler
 	iDPCH domain.IDataPointConfigHandler
+	iDRH  domain.IDriverRegistryHandler
 	e     *echo.Echo
 }
 
@@ -23,12 +24,14 @@ func (a *Api) POST(path string, handlerFunc echo.HandlerFunc, middlewareFunc ...
 	return a.e.POST(path, handlerFunc, middlewareFunc...)
 }
 
-func NewApiUsecase(logUsecase domain.ILogUsecase, appConfigUsecase domain.IAppConfigUseCase, dataPointHandler domain.IDataPointHandler, dataPointConfigHandler domain.IDataPointConfigHandler) domain.IApiUsecase {
+func NewApiUsecase(logUsecase domain.ILogUsecase, appConfigUsecase domain.IAppConfigUseCase, dataPointHandler domain.IDataPointHandler, dataPointConfigHandler domain.IDataPointConfigHandler,
+	driverRegistryHandler domain.IDriverRegistryHandler) domain.IApiUsecase {
 	d := &Api{
 		iLU:   logUsecase,
 		iACU:  appConfigUsecase,
 		iDPH:  dataPointHandler,
 		iDPCH: dataPointConfigHandler,
+		iDRH:  driverRegistryHandler,
 	}
 	a := d.iACU.GetConfig().Server.Address
 	e := echo.New()
@@ -39,6 +42,8 @@ func NewApiUsecase(logUsecase domain.ILogUsecase, appConfigUsecase domain.IAppCo
 		})
 		e.GET("/v1/getAllVariables", dataPointHandler.GetAllVariablesV1)
 		e.POST("/v1/configUpdate", dataPointConfigHandler.ConfigUpdate)
+		e.GET("/v1/drivers", driverRegistryHandler.GetDrivers)
+		e.GET("/v1/ports/status", dataPointHandler.GetPortsStatus)
 	}
 	{
 		e.GET("/v2/getAllVariables", dataPointHandler.GetAllVariablesV2)
@@ -7,8 +7,12 @@ import (
 	usecase4 "didaGatewayCenter/dataPoint/usecase"
 	http2 "didaGatewayCenter/dataPointConfig/delivery/http"
 	usecase3 "didaGatewayCenter/dataPointConfig/usecase"
+	http3 "didaGatewayCenter/driverRegistry/delivery/http"
+	usecase9 "didaGatewayCenter/driverRegistry/usecase"
 	usecase2 "didaGatewayCenter/log/usecase"
 	usecase7 "didaGatewayCenter/mqtt/usecase"
+	usecase10 "didaGatewayCenter/realtimeStore/usecase"
+	usecase8 "didaGatewayCenter/serialPenetrate/usecase"
 	usecase5 "didaGatewayCenter/systemInfo/usecase"
 	"flag"
 )
@@ -29,13 +33,17 @@ func main() {
 	iSU := usecase5.NewSystemUseCase(iLogU, iACU)
 
 	iDPCU := usecase3.NewDataPointConfigUseCase(iLogU, iACU)
-	iDPU := usecase4.NewDataPointUseCase(iLogU, iDPCU)
+	iDRU := usecase9.NewDriverRegistry(iLogU)
+	iRSU := usecase10.NewRealtimeStore()
+	iDPU := usecase4.NewDataPointUseCase(iLogU, iDPCU, iDRU, iRSU)
 	go iDPU.CycleSample()
+	iSPU := usecase8.NewSerialPenetrateUsecase(iLogU, iDPCU)
 
-	iDPH := http.NewDataPointHandler(iDPU)
+	iDPH := http.NewDataPointHandler(iDPU, iRSU)
 	iDPCH := http2.NewDataPointConfigHandler(iLogU, iACU, iDPCU)
-	api.NewApiUsecase(iLogU, iACU, iDPH, iDPCH)
+	iDRH := http3.NewDriverRegistryHandler(iDRU)
+	api.NewApiUsecase(iLogU, iACU, iDPH, iDPCH, iDRH)
 	//	usecase6.NewMqttUseCase(iACU, iLogU, iSU, iDPU)
-	usecase7.NewMqttUseCase(iACU, iLogU, iSU, iDPU)
+	usecase7.NewMqttUseCase(iACU, iLogU, iSU, iDPU, iSPU, iRSU)
 	select {}
 }
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/domain"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	mbapHeaderLength = 7
+	mbapMaxLength    = 260
+
+	// exceptionGatewayTargetFailed the serial device did not answer
+	exceptionGatewayTargetFailed = 0x0B
+)
+
+type convertUsecase struct {
+	iLogU domain.ILogUsecase
+}
+
+// Serve listens on the convert port of a serial port, requests of every client are sent on the bus
+// through the driver, so they are interleaved with the cycle sample
+func (c *convertUsecase) Serve(portConfig *domain.DataPointPortConfig, transmitter domain.IBusTransmitter) {
+	address := fmt.Sprintf(":%d", portConfig.Param.ConvertPort)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		c.iLogU.GetLogger().Error("convert port listen failed", zap.String("portName", portConfig.PortName),
+			zap.String("address", address), zap.Error(err))
+		return
+	}
+	c.iLogU.GetLogger().Info("convert port is listening", zap.String("portName", portConfig.PortName),
+		zap.String("address", address), zap.Int("convertMode", int(c.getMode(portConfig))))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				c.iLogU.GetLogger().Warn("convert port accept failed", zap.String("portName", portConfig.PortName), zap.Error(err))
+				time.Sleep(time.Second)
+				continue
+			}
+			go c.handle(portConfig, transmitter, conn)
+		}
+	}()
+}
+
+func (c *convertUsecase) handle(portConfig *domain.DataPointPortConfig, transmitter domain.IBusTransmitter, conn net.Conn) {
+	defer conn.Close()
+	remote := conn.RemoteAddr().String()
+	c.iLogU.GetLogger().Info("convert client connected", zap.String("portName", portConfig.PortName), zap.String("remote", remote))
+	timeout := connection.RespTimeout(portConfig.Param)
+	mode := c.getMode(portConfig)
+	for {
+		var err error
+		if mode == domain.ConvertModeModbusTcp {
+			err = c.modbusTcp(portConfig, transmitter, conn, timeout)
+		} else {
+			err = c.transparent(transmitter, conn, timeout)
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				c.iLogU.GetLogger().Warn("convert client error", zap.String("portName", portConfig.PortName),
+					zap.String("remote", remote), zap.Error(err))
+			}
+			c.iLogU.GetLogger().Info("convert client disconnected", zap.String("portName", portConfig.PortName), zap.String("remote", remote))
+			return
+		}
+	}
+}
+
+// modbusTcp converts one Modbus TCP request, the MBAP header is replaced by the slave address and the checksum
+// of the serial frame
+func (c *convertUsecase) modbusTcp(portConfig *domain.DataPointPortConfig, transmitter domain.IBusTransmitter, conn net.Conn, timeout time.Duration) error {
+	header := make([]byte, mbapHeaderLength)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	length := int(binary.BigEndian.Uint16(header[4:6]))
+	if length < 2 || length > mbapMaxLength-6 {
+		return fmt.Errorf("invalid mbap length: %d", length)
+	}
+	pdu := make([]byte, length-1)
+	if _, err := io.ReadFull(conn, pdu); err != nil {
+		return err
+	}
+	unitId := header[6]
+	functionCode := pdu[0]
+	isAscii := portConfig.DeviceType == domain.DeviceTypeModbusASCII
+	var request []byte
+	if isAscii {
+		request = asciiFrame(unitId, pdu)
+	} else {
+		request = rtuFrame(unitId, pdu)
+	}
+	response, err := transmitter.Transmit(request, timeout)
+	if err == nil {
+		if isAscii {
+			pdu, err = parseAsciiFrame(unitId, response)
+		} else {
+			pdu, err = parseRtuFrame(unitId, response)
+		}
+	}
+	if err != nil {
+		c.iLogU.GetLogger().Warn("convert request failed", zap.String("portName", portConfig.PortName),
+			zap.Uint8("unitId", unitId), zap.String("request", fmt.Sprintf("% X", request)), zap.Error(err))
+		pdu = []byte{functionCode | 0x80, exceptionGatewayTargetFailed}
+	}
+	binary.BigEndian.PutUint16(header[4:6], uint16(len(pdu)+1))
+	_, err = conn.Write(append(header, pdu...))
+	return err
+}
+
+// transparent forwards whatever the client sent as one frame
+func (c *convertUsecase) transparent(transmitter domain.IBusTransmitter, conn net.Conn, timeout time.Duration) error {
+	buffer := make([]byte, 1024)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		return err
+	}
+	response, err := transmitter.Transmit(buffer[:n], timeout)
+	if err != nil || len(response) == 0 {
+		return nil
+	}
+	_, err = conn.Write(response)
+	return err
+}
+
+// getMode only Modbus serial ports can convert Modbus TCP
+func (c *convertUsecase) getMode(portConfig *domain.DataPointPortConfig) domain.ConvertMode {
+	switch portConfig.DeviceType {
+	case domain.DeviceTypeModbusRTU, domain.DeviceTypeModbusASCII:
+		return portConfig.Param.ConvertMode
+	}
+	return domain.ConvertModeTransparent
+}
+
+func NewConvertUsecase(iLogU domain.ILogUsecase) domain.IConvertUsecase {
+	c := convertUsecase{iLogU: iLogU}
+	return &c
+}
+
+func rtuFrame(unitId byte, pdu []byte) []byte {
+	result := append([]byte{unitId}, pdu...)
+	crc := make([]byte, 2)
+	binary.LittleEndian.PutUint16(crc, crc16(result))
+	return append(result, crc...)
+}
+
+func parseRtuFrame(unitId byte, input []byte) ([]byte, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if crc := crc16(input[:len(input)-2]); crc != binary.LittleEndian.Uint16(input[len(input)-2:]) {
+		return nil, fmt.Errorf("crc is %X not match % X which received", crc, input[len(input)-2:])
+	}
+	if input[0] != unitId {
+		return nil, fmt.Errorf("unexpected slave address: %d", input[0])
+	}
+	return input[1 : len(input)-2], nil
+}
+
+// asciiFrame ':' + hex(slave address, pdu, lrc) + CR LF
+func asciiFrame(unitId byte, pdu []byte) []byte {
+	data := append([]byte{unitId}, pdu...)
+	data = append(data, lrc(data))
+	return []byte(fmt.Sprintf(":%X\r\n", data))
+}
+
+func parseAsciiFrame(unitId byte, input []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(input))
+	if !strings.HasPrefix(text, ":") {
+		return nil, fmt.Errorf("invalid frame: %q", input)
+	}
+	data, err := hex.DecodeString(text[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 3 {
+		return nil, fmt.Errorf("invalid length: %d", len(data))
+	}
+	if sum := lrc(data[:len(data)-1]); sum != data[len(data)-1] {
+		return nil, fmt.Errorf("lrc is %X not match %X which received", sum, data[len(data)-1])
+	}
+	if data[0] != unitId {
+		return nil, fmt.Errorf("unexpected slave address: %d", data[0])
+	}
+	return data[1 : len(data)-1], nil
+}
+
+func crc16(input []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, temp := range input {
+		crc ^= uint16(temp)
+		for i := 0; i < 8; i++ {
+			if crc&0x0001 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+func lrc(input []byte) byte {
+	sum := byte(0)
+	for _, temp := range input {
+		sum += temp
+	}
+	return -sum
+}
@@ -6,18 +6,20 @@ import (
 )
 
 type DataPointHandler struct {
-	iDPU domain.IDataPointUseCase
+	iDPU  domain.IDataPointUseCase
+	store domain.IRealtimeStore
 }
 
-func NewDataPointHandler(useCase domain.IDataPointUseCase) domain.IDataPointHandler {
+func NewDataPointHandler(useCase domain.IDataPointUseCase, store domain.IRealtimeStore) domain.IDataPointHandler {
 	handler := &DataPointHandler{
-		iDPU: useCase,
+		iDPU:  useCase,
+		store: store,
 	}
 	return handler
 }
 
 func (i *DataPointHandler) GetAllVariablesV2(ctx echo.Context) error {
-	a := i.iDPU.GetStore()
+	a := i.store.Snapshot()
 	ret := make(map[string]interface{})
 	ret["ret"] = a
 	ctx.Response().Header().Set("Content-Type", "application/json")
@@ -25,7 +27,7 @@ func (i *DataPointHandler) GetAllVariablesV2(ctx echo.Context) error {
 }
 
 func (i *DataPointHandler) GetAllVariablesV1(ctx echo.Context) error {
-	a := i.iDPU.GetStore()
+	a := i.store.Snapshot()
 	ret := make(map[string]map[string]map[string]interface{})
 
 	for _, singleDataPoint := range a {
@@ -40,3 +42,11 @@ func (i *DataPointHandler) GetAllVariablesV1(ctx echo.Context) error {
 	ctx.Response().Header().Set("Content-Type", "application/json")
 	return ctx.JSON(200, ret)
 }
+
+// GetPortsStatus the connection health of every sampled port
+func (i *DataPointHandler) GetPortsStatus(ctx echo.Context) error {
+	ret := make(map[string]interface{})
+	ret["ret"] = i.iDPU.PortsHealth()
+	ctx.Response().Header().Set("Content-Type", "application/json")
+	return ctx.JSON(200, ret)
+}
@@ -1,68 +1,71 @@
 package usecase
 
 import (
-	"didaGatewayCenter/dataPointDriver/modbus"
-	"didaGatewayCenter/dataPointDriver/plc/mitsubishi"
-	"didaGatewayCenter/dataPointDriver/plc/siemens"
+	"didaGatewayCenter/convert/usecase"
 	"didaGatewayCenter/dataTransform"
 	"didaGatewayCenter/domain"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type dataPointUsecase struct {
 	dataPoints []*domain.DataPoint
 	logUsecase domain.ILogUsecase
+	store      domain.IRealtimeStore
 }
 
 func (d *dataPointUsecase) WriteById(id int64, value interface{}) (interface{}, error) {
-	portConfig := &domain.DataPointPortConfig{}
-	deviceList := &domain.DeviceList{}
-	var variableList *domain.DataPointVariableList
-	var driver domain.IDataPointDriverUsecase
-	//dataPointIndex := 0
-	portConfig, deviceList, variableList, driver = d.findVariableById(id)
-
+	dataPoint, deviceList, variableList := d.findVariableById(id)
 	if variableList == nil {
 		return nil, fmt.Errorf("variable name is not found")
 	}
-	if err := driver.Write(portConfig, deviceList, variableList, value); err != nil {
-		//	if err := d.dataPoints[dataPointIndex].Driver.Write(portConfig, deviceList, variableList, value); err != nil {
+	value, err := writeValue(variableList, value)
+	if err != nil {
 		return nil, err
 	}
-	value, err := d.ReadById(id, true)
+	// the drivers get copies, some of them adjust the config while reading
+	variable := *variableList
+	if err := dataPoint.Driver.Write(dataPoint.PortConfig, deviceList, &variable, value); err != nil {
+		return nil, err
+	}
+	// the value read back is stored at once, the subscribers of the store do not wait for the next sample
+	result, err := dataPoint.Driver.Read(dataPoint.PortConfig, deviceList, &variable)
 	if err != nil {
 		return nil, err
 	}
-	return value, nil
+	d.sample(variableList, result, nil, 0)
+	return sampleValue(result), nil
 }
 
-func (d *dataPointUsecase) GetStore() []domain.AllDataPoints {
-	var ret []domain.AllDataPoints
+func (d *dataPointUsecase) PortsHealth() []domain.PortHealth {
+	ret := make([]domain.PortHealth, 0, len(d.dataPoints))
 	for _, singleDataPoint := range d.dataPoints {
-		for _, singleVariable := range singleDataPoint.VariableConfig {
-			for _, singleVariableList := range singleVariable.VarList {
-				ret = append(ret, domain.AllDataPoints{
-					PortName:     singleVariable.PortName,
-					DeviceName:   singleVariable.DevName,
-					VariableName: singleVariableList.Name,
-					Value:        singleVariableList.Value,
-					Timestamp:    singleVariableList.Timestamp,
-				})
-			}
+		var health domain.PortHealth
+		if reporter, ok := singleDataPoint.Driver.(domain.IHealthReporter); ok {
+			health = reporter.Health()
 		}
+		health.PortName = singleDataPoint.PortConfig.PortName
+		health.DeviceType = singleDataPoint.PortConfig.DeviceType
+		ret = append(ret, health)
 	}
 	return ret
 }
 
-func NewDataPointUseCase(logUc domain.ILogUsecase, dataPointConfig domain.IDataPointConfigUseCase) domain.IDataPointUseCase {
+func NewDataPointUseCase(logUc domain.ILogUsecase, dataPointConfig domain.IDataPointConfigUseCase, driverRegistry domain.IDriverRegistry,
+	store domain.IRealtimeStore) domain.IDataPointUseCase {
 	d := &dataPointUsecase{
 		logUsecase: logUc,
+		store:      store,
 	}
 	dataPointPorts := dataPointConfig.GetPortConfigs()
 
 	var dataPointDriver domain.IDataPointDriverUsecase
+	convertUsecase := usecase.NewConvertUsecase(logUc)
 
 	for index, singleDataPointPort := range dataPointPorts.PortConfigs {
 		if !singleDataPointPort.Vaild {
@@ -74,163 +77,247 @@ func NewDataPointUseCase(logUc domain.ILogUsecase, dataPointConfig domain.IDataP
 		tempDataPoint.PortConfig = &dataPointPorts.PortConfigs[index]
 		tempDataPoint.DeviceConfig = dataPointConfig.GetDeviceConfigs(portName)
 		tempDataPoint.VariableConfig = dataPointConfig.GetVariableConfigs(portName)
-		switch singleDataPointPort.DeviceType {
-		case domain.DeviceTypeModbusRTU, domain.DeviceTypeModbusTCP, domain.DeviceTypeModbusASCII:
-			dataPointDriver = modbus.NewModbusUsecase(logUc)
-		case domain.DeviceTypeSiemensS200Smart:
-			dataPointDriver = siemens.NewSiemensDriver(logUc)
-		case domain.DeviceTypeMCAsciiQna3E, domain.DeviceTypeMCBinaryQna3E, domain.DeviceTypeMitsubishiProgramPort,
-			domain.DeviceTypeMitsubishiComputerLink:
-			dataPointDriver = mitsubishi.NewMitsubishiUsecaseDriver(logUc)
-		default:
+		driverInfo, ok := driverRegistry.Lookup(singleDataPointPort.DeviceType)
+		if !ok {
+			logUc.GetLogger().Error("unsupported device type, the port is not sampled", zap.String("port", portName),
+				zap.Int("deviceType", int(singleDataPointPort.DeviceType)))
 			continue
 		}
+		if !driverInfo.SupportsPortType(singleDataPointPort.PortType) {
+			logUc.GetLogger().Warn("the device type is not made for the port type", zap.String("port", portName),
+				zap.String("driver", driverInfo.Name), zap.Int("portType", int(singleDataPointPort.PortType)))
+		}
+		for _, singleVariableConfig := range tempDataPoint.VariableConfig {
+			for _, singleVariableList := range singleVariableConfig.VarList {
+				if err := store.Register(singleVariableList.Id, singleVariableConfig.PortName, singleVariableConfig.DevName, singleVariableList.Name); err != nil {
+					logUc.GetLogger().Error("the variable is not sampled", zap.String("port", portName),
+						zap.String("device", singleVariableConfig.DevName), zap.String("variable", singleVariableList.Name), zap.Error(err))
+				}
+			}
+		}
+		dataPointDriver = driverInfo.Factory(logUc, d)
+		tempDataPoint.DriverInfo = driverInfo
 		tempDataPoint.Driver = dataPointDriver
 		d.dataPoints = append(d.dataPoints, &tempDataPoint)
 		dataPointDriver.Init(&dataPointPorts.PortConfigs[index], dataTransform.NewDataTransformUsecase())
+		if singleDataPointPort.PortType == domain.SerialType && singleDataPointPort.Param.ConvertEnable {
+			if transmitter, ok := dataPointDriver.(domain.IBusTransmitter); ok {
+				convertUsecase.Serve(&dataPointPorts.PortConfigs[index], transmitter)
+			} else {
+				logUc.GetLogger().Warn("the driver of the port does not support converting, skipping", zap.String("port", portName))
+			}
+		}
 	}
 	return d
 }
+
 func (d *dataPointUsecase) Read(portName string, deviceName string, variableName string, isRealTime bool) (interface{}, error) {
-	portConfig := &domain.DataPointPortConfig{}
-	deviceList := &domain.DeviceList{}
-	var variableList *domain.DataPointVariableList
-	dataPointIndex := 0
-	for index, singleDataPoint := range d.dataPoints {
-		if portName != "" && singleDataPoint.PortConfig.PortName != portName {
+	if !isRealTime {
+		sample, ok := d.store.Lookup(portName, deviceName, variableName)
+		if !ok {
+			return nil, fmt.Errorf("variable name is not found")
+		}
+		return sample.Value, nil
+	}
+	for _, singleDataPoint := range d.dataPoints {
+		if singleDataPoint.PortConfig.PortName != portName {
 			continue
 		}
-		portConfig = singleDataPoint.PortConfig
-		for _, singleDeviceList := range singleDataPoint.DeviceConfig.DevList {
-			if deviceName != "" && singleDeviceList.DevName != deviceName {
-				continue
-			}
-			deviceList = singleDeviceList
+		deviceList := findDevice(singleDataPoint, deviceName)
+		if deviceList == nil {
+			break
 		}
-
 		for _, singleVariableConfig := range singleDataPoint.VariableConfig {
-
-			if singleVariableConfig.PortName != portName {
-				continue
-			} else if singleVariableConfig.DevName != deviceName {
+			if singleVariableConfig.PortName != portName || singleVariableConfig.DevName != deviceName {
 				continue
 			}
-			for _, singleVariableList := range singleVariableConfig.VarList {
-				if singleVariableList.Name != variableName {
+			for index := range singleVariableConfig.VarList {
+				if singleVariableConfig.VarList[index].Name != variableName {
 					continue
 				}
-				if variableList == nil {
-					variableList = &domain.DataPointVariableList{}
+				variable := singleVariableConfig.VarList[index]
+				result, err := singleDataPoint.Driver.Read(singleDataPoint.PortConfig, deviceList, &variable)
+				if err != nil {
+					return nil, err
 				}
-				variableList = &singleVariableList
-				dataPointIndex = index
+				return sampleValue(result), nil
 			}
-			break
 		}
 	}
-	if variableList == nil {
-		return nil, fmt.Errorf("variable name is not found")
-	}
-	var value interface{}
-	if isRealTime {
-
-		value = d.dataPoints[dataPointIndex].Driver.Read(portConfig, deviceList, variableList).ToFloat64()
-	} else {
-		value = variableList.Value
-	}
-	return value, nil
+	return nil, fmt.Errorf("variable name is not found")
 }
 
 func (d *dataPointUsecase) ReadById(id int64, isRealTime bool) (interface{}, error) {
-	portConfig := &domain.DataPointPortConfig{}
-	deviceList := &domain.DeviceList{}
-
-	var (
-		variableList *domain.DataPointVariableList
-		driver       domain.IDataPointDriverUsecase
-	)
-
-	//	dataPointIndex := 0
-	portConfig, deviceList, variableList, driver = d.findVariableById(id)
+	if !isRealTime {
+		sample, ok := d.store.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("variable name is not found")
+		}
+		return sample.Value, nil
+	}
+	dataPoint, deviceList, variableList := d.findVariableById(id)
 	if variableList == nil {
 		return nil, fmt.Errorf("variable name is not found")
 	}
-	var value interface{}
-	if isRealTime {
-		tempValue := driver.Read(portConfig, deviceList, variableList)
-		if tempValue == nil {
-			return nil, nil
-		}
-		return tempValue.ToFloat64(), nil
-	} else {
-		value = variableList.Value
+	variable := *variableList
+	result, err := dataPoint.Driver.Read(dataPoint.PortConfig, deviceList, &variable)
+	if err != nil {
+		return nil, err
 	}
-	return value, nil
+	return sampleValue(result), nil
 }
 
-func (d *dataPointUsecase) findVariableById(id int64) (*domain.DataPointPortConfig, *domain.DeviceList, *domain.DataPointVariableList, domain.IDataPointDriverUsecase) {
-	portName := ""
-	deviceName := ""
-	portConfig := &domain.DataPointPortConfig{}
-	devList := &domain.DeviceList{}
-	var variableList *domain.DataPointVariableList
-	var driver domain.IDataPointDriverUsecase
+// findVariableById the variable in the config of its port, the drivers have to be given copies of it
+func (d *dataPointUsecase) findVariableById(id int64) (*domain.DataPoint, *domain.DeviceList, *domain.DataPointVariableList) {
 	for _, singleDataPoint := range d.dataPoints {
 		for _, singleVariableConfig := range singleDataPoint.VariableConfig {
-			for _, singleVarList := range singleVariableConfig.VarList {
-				if singleVarList.Id != id {
+			for index := range singleVariableConfig.VarList {
+				if singleVariableConfig.VarList[index].Id != id {
 					continue
 				}
-				portName = singleVariableConfig.PortName
-				deviceName = singleVariableConfig.DevName
-				variableList = &singleVarList
-				break
-			}
-			if portName != "" && deviceName != "" {
-				break
-			}
-		}
-		for _, singleDevList := range singleDataPoint.DeviceConfig.DevList {
-			if singleDevList.DevName != deviceName {
-				continue
+				deviceList := findDevice(singleDataPoint, singleVariableConfig.DevName)
+				if deviceList == nil {
+					return nil, nil, nil
+				}
+				return singleDataPoint, deviceList, &singleVariableConfig.VarList[index]
 			}
-			devList = singleDevList
-			break
 		}
-		if portName != "" && devList.DevName != "" {
-			portConfig = singleDataPoint.PortConfig
-			driver = singleDataPoint.Driver
-			break
+	}
+	return nil, nil, nil
+}
+
+func findDevice(dataPoint *domain.DataPoint, deviceName string) *domain.DeviceList {
+	for _, singleDevList := range dataPoint.DeviceConfig.DevList {
+		if singleDevList.DevName == deviceName {
+			return singleDevList
 		}
 	}
-	return portConfig, devList, variableList, driver
+	return nil
 }
+
 func (d *dataPointUsecase) CycleSample() {
+	for _, singleDataPoint := range d.dataPoints {
+		go d.scan(singleDataPoint)
+	}
+}
 
-	for index, _ := range d.dataPoints {
-		go func(tempIndex int, tempSingleDataPointPort *domain.DataPoint) {
-			for {
-				for _, singleDeviceList := range tempSingleDataPointPort.DeviceConfig.DevList {
-
-					for index2, singleVariableConfig := range tempSingleDataPointPort.VariableConfig {
-						if singleVariableConfig.PortName != tempSingleDataPointPort.PortConfig.PortName ||
-							singleVariableConfig.DevName != singleDeviceList.DevName {
-							continue
-						}
-						for index3, singleVariableList := range singleVariableConfig.VarList {
-
-							value := tempSingleDataPointPort.Driver.Read(tempSingleDataPointPort.PortConfig, singleDeviceList, &singleVariableList)
-							if value == nil {
-								tempSingleDataPointPort.VariableConfig[index2].VarList[index3].Value = nil
-							} else {
-								tempSingleDataPointPort.VariableConfig[index2].VarList[index3].Value = value.ToFloat64()
-							}
-							tempSingleDataPointPort.VariableConfig[index2].VarList[index3].Timestamp = time.Now()
-						}
-					}
-				}
-				time.Sleep(time.Second * time.Duration(tempSingleDataPointPort.PortConfig.Param.SampleIntervalS))
-			}
-		}(index, d.dataPoints[index])
+// staleCycles failed sample cycles after which the last good value is no longer given as the value
+const staleCycles = 3
+
+// staleWindow how long the last good value of a variable sampled every interval is kept
+func staleWindow(interval time.Duration) time.Duration {
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval * staleCycles
+}
+
+// sample stores the result of a read, a failed read keeps the last good value for a while as uncertain, the
+// variables the device cannot address are bad config
+func (d *dataPointUsecase) sample(variable *domain.DataPointVariableList, value domain.IValueType, err error, staleAfter time.Duration) {
+	if err == nil && value == nil {
+		err = errors.New("no value was read from the device")
+	}
+	var driverErr *domain.DriverError
+	if errors.As(err, &driverErr) && driverErr.Kind == domain.DriverErrorInvalidAddress {
+		d.badConfig(variable, err)
+		return
+	}
+	_ = d.store.Update(variable.Id, func(sample *domain.Sample) {
+		now := time.Now()
+		sample.Timestamp = now
+		if err == nil {
+			sample.Value = sampleValue(value)
+			sample.Quality = domain.QualityGood
+			sample.LastGoodValue = sample.Value
+			sample.LastGoodTimestamp = now
+			sample.SourceError = ""
+			return
+		}
+		sample.SourceError = err.Error()
+		if !sample.LastGoodTimestamp.IsZero() && now.Sub(sample.LastGoodTimestamp) <= staleAfter {
+			sample.Value = sample.LastGoodValue
+			sample.Quality = domain.QualityUncertainStale
+			return
+		}
+		sample.Value = nil
+		sample.Quality = domain.QualityBadCommFailure
+	})
+}
+
+func (d *dataPointUsecase) badConfig(variable *domain.DataPointVariableList, err error) {
+	_ = d.store.Update(variable.Id, func(sample *domain.Sample) {
+		sample.Value = nil
+		sample.Timestamp = time.Now()
+		sample.Quality = domain.QualityBadConfig
+		sample.SourceError = err.Error()
+	})
+}
+
+// configError the variables which the driver of the port cannot read
+func configError(driverInfo *domain.DriverInfo, variable *domain.DataPointVariableList) error {
+	if !driverInfo.SupportsDataType(variable.DataType) {
+		return fmt.Errorf("data type %d is not supported by %s", variable.DataType, driverInfo.Name)
+	}
+	if !driverInfo.SupportsRegisterType(variable.Param.RegType) {
+		return fmt.Errorf("register type %d is not supported by %s", variable.Param.RegType, driverInfo.Name)
+	}
+	if variable.DataType == domain.VarDataTypeString && variable.Param.StrLength <= 0 {
+		return fmt.Errorf("the length of string variable %s is not set", variable.Name)
+	}
+	return nil
+}
+
+// sampleValue the values are stored in their native type, bool, int64, uint64, float64 or string
+func sampleValue(value domain.IValueType) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value.Value()
+}
+
+// writeValue the drivers take a string for string variables, an int64, uint64 or float64 for the others
+func writeValue(variableList *domain.DataPointVariableList, value interface{}) (interface{}, error) {
+	if number, ok := value.(json.Number); ok {
+		value = number.String()
+	}
+	if variableList.DataType == domain.VarDataTypeString {
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case uint64:
+			return strconv.FormatUint(v, 10), nil
+		}
+		return nil, fmt.Errorf("invalid value type %T of %s", value, variableList.Name)
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64, uint64:
+		// the drivers write integers exactly, float64 loses them above 2^53
+		return v, nil
+	case bool:
+		if v {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	case string:
+		text := strings.TrimSpace(v)
+		if integer, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return integer, nil
+		}
+		if integer, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return integer, nil
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q of %s", v, variableList.Name)
+		}
+		return number, nil
 	}
+	return nil, fmt.Errorf("invalid value type %T of %s", value, variableList.Name)
 }
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"didaGatewayCenter/dataTransform"
+	"didaGatewayCenter/domain"
+	realtimeStore "didaGatewayCenter/realtimeStore/usecase"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStaleWindow(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     time.Duration
+	}{
+		{100 * time.Millisecond, 3 * time.Second},
+		{time.Second, 3 * time.Second},
+		{10 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := staleWindow(tt.interval); got != tt.want {
+			t.Fatalf("staleWindow(%v) = %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestSampleQuality(t *testing.T) {
+	store := realtimeStore.NewRealtimeStore()
+	if err := store.Register(1, "port", "device", "variable"); err != nil {
+		t.Fatal(err)
+	}
+	d := &dataPointUsecase{store: store}
+	variable := &domain.DataPointVariableList{Id: 1, Name: "variable"}
+	readFailed := &domain.DriverError{Kind: domain.DriverErrorTimeout, Err: errors.New("no response")}
+
+	check := func(step string, quality domain.Quality, value interface{}, sourceError string) {
+		t.Helper()
+		sample, _ := store.Get(1)
+		if sample.Quality != quality || sample.Value != value || sample.SourceError != sourceError {
+			t.Fatalf("%s: sample = %s %v %q, want %s %v %q", step, sample.Quality, sample.Value, sample.SourceError,
+				quality, value, sourceError)
+		}
+	}
+
+	d.sample(variable, nil, readFailed, time.Minute)
+	check("never read", domain.QualityBadCommFailure, nil, readFailed.Error())
+
+	d.sample(variable, dataTransform.NewValue(int64(42)), nil, time.Minute)
+	check("good read", domain.QualityGood, int64(42), "")
+
+	d.sample(variable, nil, readFailed, time.Minute)
+	check("failed within the window", domain.QualityUncertainStale, int64(42), readFailed.Error())
+	if sample, _ := store.Get(1); sample.LastGoodValue != int64(42) {
+		t.Fatalf("last good value = %v, want 42", sample.LastGoodValue)
+	}
+
+	d.sample(variable, nil, readFailed, -time.Second)
+	check("failed after the window", domain.QualityBadCommFailure, nil, readFailed.Error())
+
+	d.sample(variable, nil, nil, time.Minute)
+	check("nothing read", domain.QualityUncertainStale, int64(42), "no value was read from the device")
+
+	invalid := &domain.DriverError{Kind: domain.DriverErrorInvalidAddress, Err: errors.New("unknown area")}
+	d.sample(variable, nil, invalid, time.Minute)
+	check("invalid address", domain.QualityBadConfig, nil, invalid.Error())
+}
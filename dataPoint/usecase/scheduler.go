@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"didaGatewayCenter/domain"
+	"go.uber.org/zap"
+	"time"
+)
+
+// defaultMinInterval the shortest interval of a scan class when the port does not set one
+const defaultMinInterval = 100 * time.Millisecond
+
+// scanItem the variables of a device sampled together, a block of one class for the block readers and a single
+// variable for the others
+type scanItem struct {
+	device         *domain.DeviceList
+	variableConfig *domain.DataPointVariableConfig
+	indexes        []int
+	interval       time.Duration
+	due            time.Time
+}
+
+// scanRates the intervals of the scan classes of a port, no interval is shorter than the min interval
+func scanRates(portConfig *domain.DataPointPortConfig) map[domain.ScanClass]time.Duration {
+	param := portConfig.Param
+	minInterval := time.Duration(param.MinIntervalMs) * time.Millisecond
+	if minInterval <= 0 {
+		minInterval = defaultMinInterval
+	}
+	normal := time.Duration(param.NormalIntervalMs) * time.Millisecond
+	if normal <= 0 {
+		normal = time.Duration(param.SampleIntervalS) * time.Second
+	}
+	fast := time.Duration(param.FastIntervalMs) * time.Millisecond
+	if fast <= 0 {
+		fast = normal / 5
+	}
+	slow := time.Duration(param.SlowIntervalMs) * time.Millisecond
+	if slow <= 0 {
+		slow = normal * 10
+	}
+	rates := map[domain.ScanClass]time.Duration{
+		domain.ScanClassFast:   fast,
+		domain.ScanClassNormal: normal,
+		domain.ScanClassSlow:   slow,
+	}
+	for class, interval := range rates {
+		if interval < minInterval {
+			rates[class] = minInterval
+		}
+	}
+	return rates
+}
+
+// scanClass the class of a variable, the variables without one take the class of their device
+func scanClass(device *domain.DeviceList, variable *domain.DataPointVariableList) domain.ScanClass {
+	class := variable.ScanClass
+	if class == domain.ScanClassDefault {
+		class = device.ScanClass
+	}
+	switch class {
+	case domain.ScanClassFast, domain.ScanClassSlow:
+		return class
+	}
+	return domain.ScanClassNormal
+}
+
+// scanItems the items of a port, all of them are due at once, the variables the driver cannot read are marked bad
+// config and left out, so are the variables the store rejected
+func (d *dataPointUsecase) scanItems(dataPoint *domain.DataPoint) []*scanItem {
+	rates := scanRates(dataPoint.PortConfig)
+	_, isBlockReader := dataPoint.Driver.(domain.IBlockReader)
+	now := time.Now()
+	var items []*scanItem
+	for _, singleDeviceList := range dataPoint.DeviceConfig.DevList {
+		for _, singleVariableConfig := range dataPoint.VariableConfig {
+			if singleVariableConfig.PortName != dataPoint.PortConfig.PortName ||
+				singleVariableConfig.DevName != singleDeviceList.DevName {
+				continue
+			}
+			blocks := make(map[domain.ScanClass]*scanItem)
+			for index := range singleVariableConfig.VarList {
+				variable := &singleVariableConfig.VarList[index]
+				if !d.isStored(singleVariableConfig, variable) {
+					continue
+				}
+				if err := configError(dataPoint.DriverInfo, variable); err != nil {
+					d.badConfig(variable, err)
+					continue
+				}
+				class := scanClass(singleDeviceList, variable)
+				if item, ok := blocks[class]; ok {
+					item.indexes = append(item.indexes, index)
+					continue
+				}
+				item := &scanItem{
+					device:         singleDeviceList,
+					variableConfig: singleVariableConfig,
+					indexes:        []int{index},
+					interval:       rates[class],
+					due:            now,
+				}
+				if isBlockReader {
+					blocks[class] = item
+				}
+				items = append(items, item)
+			}
+		}
+	}
+	d.logUsecase.GetLogger().Info("scan classes of the port", zap.String("port", dataPoint.PortConfig.PortName),
+		zap.Duration("fast", rates[domain.ScanClassFast]), zap.Duration("normal", rates[domain.ScanClassNormal]),
+		zap.Duration("slow", rates[domain.ScanClassSlow]), zap.Int("items", len(items)))
+	return items
+}
+
+// isStored whether the sample of the variable in the store is its own, the store rejects duplicate ids and names
+func (d *dataPointUsecase) isStored(variableConfig *domain.DataPointVariableConfig, variable *domain.DataPointVariableList) bool {
+	sample, ok := d.store.Get(variable.Id)
+	return ok && sample.PortName == variableConfig.PortName && sample.DeviceName == variableConfig.DevName &&
+		sample.VariableName == variable.Name
+}
+
+// scan samples the items of a port in the order they are due, the bus only waits when no item is due
+func (d *dataPointUsecase) scan(dataPoint *domain.DataPoint) {
+	items := d.scanItems(dataPoint)
+	if len(items) == 0 {
+		return
+	}
+	for {
+		next := items[0]
+		for _, item := range items[1:] {
+			if item.due.Before(next.due) {
+				next = item
+			}
+		}
+		if wait := time.Until(next.due); wait > 0 {
+			time.Sleep(wait)
+		}
+		d.sampleItem(dataPoint, next)
+		// an item which is late does not catch up, it takes its turn again after the items due before it
+		next.due = next.due.Add(next.interval)
+		if now := time.Now(); next.due.Before(now) {
+			next.due = now
+		}
+	}
+}
+
+// sampleItem reads the variables of the item and stores the results
+func (d *dataPointUsecase) sampleItem(dataPoint *domain.DataPoint, item *scanItem) {
+	varList := item.variableConfig.VarList
+	staleAfter := staleWindow(item.interval)
+	blockReader, ok := dataPoint.Driver.(domain.IBlockReader)
+	if !ok {
+		for _, index := range item.indexes {
+			// the drivers get copies, some of them adjust the config while reading
+			variable := varList[index]
+			value, err := dataPoint.Driver.Read(dataPoint.PortConfig, item.device, &variable)
+			d.sample(&varList[index], value, err, staleAfter)
+		}
+		return
+	}
+	variables := make([]*domain.DataPointVariableList, 0, len(item.indexes))
+	for _, index := range item.indexes {
+		variable := varList[index]
+		variables = append(variables, &variable)
+	}
+	values, errs := blockReader.ReadBlock(dataPoint.PortConfig, item.device, variables)
+	for i, index := range item.indexes {
+		var value domain.IValueType
+		var err error
+		if i < len(values) {
+			value = values[i]
+		}
+		if i < len(errs) {
+			err = errs[i]
+		}
+		d.sample(&varList[index], value, err, staleAfter)
+	}
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"didaGatewayCenter/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanRates(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name  string
+		param domain.PortParam
+		want  map[domain.ScanClass]time.Duration
+	}{
+		{
+			name:  "derived from the sample interval",
+			param: domain.PortParam{SampleIntervalS: 1},
+			want:  map[domain.ScanClass]time.Duration{domain.ScanClassFast: 200 * ms, domain.ScanClassNormal: time.Second, domain.ScanClassSlow: 10 * time.Second},
+		},
+		{
+			name:  "set per class",
+			param: domain.PortParam{SampleIntervalS: 1, FastIntervalMs: 250, NormalIntervalMs: 2000, SlowIntervalMs: 60000},
+			want:  map[domain.ScanClass]time.Duration{domain.ScanClassFast: 250 * ms, domain.ScanClassNormal: 2 * time.Second, domain.ScanClassSlow: time.Minute},
+		},
+		{
+			name:  "zero interval is no busy loop",
+			param: domain.PortParam{},
+			want:  map[domain.ScanClass]time.Duration{domain.ScanClassFast: 100 * ms, domain.ScanClassNormal: 100 * ms, domain.ScanClassSlow: 100 * ms},
+		},
+		{
+			name:  "min interval",
+			param: domain.PortParam{NormalIntervalMs: 500, MinIntervalMs: 300},
+			want:  map[domain.ScanClass]time.Duration{domain.ScanClassFast: 300 * ms, domain.ScanClassNormal: 500 * ms, domain.ScanClassSlow: 5 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scanRates(&domain.DataPointPortConfig{Param: tt.param})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("scanRates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanClass(t *testing.T) {
+	tests := []struct {
+		name     string
+		device   domain.ScanClass
+		variable domain.ScanClass
+		want     domain.ScanClass
+	}{
+		{"defaults to normal", domain.ScanClassDefault, domain.ScanClassDefault, domain.ScanClassNormal},
+		{"class of the device", domain.ScanClassSlow, domain.ScanClassDefault, domain.ScanClassSlow},
+		{"variable overrides the device", domain.ScanClassSlow, domain.ScanClassFast, domain.ScanClassFast},
+		{"unknown class is normal", domain.ScanClassDefault, domain.ScanClass(9), domain.ScanClassNormal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scanClass(&domain.DeviceList{ScanClass: tt.device}, &domain.DataPointVariableList{ScanClass: tt.variable})
+			if got != tt.want {
+				t.Fatalf("scanClass() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -62,11 +62,15 @@ func (d *dataPointConfigUsecase) GetVariableConfigs(portName string) []*domain.D
 	return temp
 }
 
+// ConvertComToDeviceNode the device node of serial port COM<comNum>, the \\.\ prefix lets windows open COM10 and above
 func ConvertComToDeviceNode(comNum int) string {
-	if runtime.GOOS != "linux" {
-		return fmt.Sprintf("COM%d", comNum)
+	switch runtime.GOOS {
+	case "linux":
+		return fmt.Sprintf("/dev/COM%d", comNum)
+	case "windows":
+		return fmt.Sprintf("\\\\.\\COM%d", comNum)
 	}
-	return fmt.Sprintf("/dev/COM%d", comNum)
+	return fmt.Sprintf("COM%d", comNum)
 }
 
 func NewDataPointConfigUseCase(iLogU domain.ILogUsecase, iAu domain.IAppConfigUseCase) domain.IDataPointConfigUseCase {
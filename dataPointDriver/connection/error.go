@@ -0,0 +1,56 @@
+package connection
+
+import (
+	"didaGatewayCenter/domain"
+	"errors"
+	"fmt"
+	"github.com/goburrow/serial"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// ErrNotConnected the requests made while the port is reconnecting
+var ErrNotConnected = &domain.DriverError{Kind: domain.DriverErrorConnectionLost, Err: errors.New("port is not connected")}
+
+// Classify turns the errors of the transports into driver errors, driver errors are returned as they are and the
+// errors which are not from the transport are invalid responses
+func Classify(err error) *domain.DriverError {
+	if err == nil {
+		return nil
+	}
+	var driverErr *domain.DriverError
+	if errors.As(err, &driverErr) {
+		return driverErr
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, serial.ErrTimeout) {
+		return &domain.DriverError{Kind: domain.DriverErrorTimeout, Err: err}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &domain.DriverError{Kind: domain.DriverErrorTimeout, Err: err}
+	}
+	var opErr *net.OpError
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &opErr) {
+		return &domain.DriverError{Kind: domain.DriverErrorConnectionLost, Err: err}
+	}
+	return &domain.DriverError{Kind: domain.DriverErrorInvalidResponse, Err: err}
+}
+
+// Exception the device rejected the request with code
+func Exception(code int, err error) *domain.DriverError {
+	return &domain.DriverError{Kind: domain.DriverErrorException, Code: code, Err: err}
+}
+
+// InvalidAddress the variable cannot be addressed as configured
+func InvalidAddress(format string, a ...interface{}) *domain.DriverError {
+	return &domain.DriverError{Kind: domain.DriverErrorInvalidAddress, Err: fmt.Errorf(format, a...)}
+}
+
+// InvalidResponse the response cannot be parsed or converted
+func InvalidResponse(err error) *domain.DriverError {
+	return &domain.DriverError{Kind: domain.DriverErrorInvalidResponse, Err: err}
+}
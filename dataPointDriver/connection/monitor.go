@@ -0,0 +1,152 @@
+package connection
+
+import (
+	"didaGatewayCenter/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// responseSamples response times kept for the average and the p95
+const responseSamples = 100
+
+// Monitor counts the consecutive timeouts of a connection and keeps its health, the drivers hold one per connection
+type Monitor struct {
+	timeouts        int
+	maxTimeouts     int
+	connected       bool
+	lastConnectTime time.Time
+	reconnectCount  int
+	lastError       error
+	lastErrorTime   time.Time
+	requests        uint64
+	responses       uint64
+	started         time.Time
+	responseTimes   []time.Duration
+	next            int
+	lock            sync.Mutex
+}
+
+// SetMaxTimeouts the consecutive timeouts after which Failed asks to reopen the connection, the default when not set
+func (m *Monitor) SetMaxTimeouts(maxTimeouts int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.maxTimeouts = maxTimeouts
+}
+
+// Connected records that the connection is open
+func (m *Monitor) Connected() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.lastConnectTime.IsZero() {
+		m.reconnectCount++
+	}
+	m.connected = true
+	m.lastConnectTime = time.Now()
+	m.timeouts = 0
+}
+
+// Disconnected records that the connection is closed and has to be reopened
+func (m *Monitor) Disconnected() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.connected = false
+}
+
+// Begin marks the start of a request, the response time is measured until Succeeded or Failed
+func (m *Monitor) Begin() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.started = time.Now()
+}
+
+// Failed records a failed request, true when the connection has to be reopened, after a lost connection or too many
+// timeouts in a row
+func (m *Monitor) Failed(err *domain.DriverError) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.requests++
+	m.lastError = err
+	m.lastErrorTime = time.Now()
+	switch err.Kind {
+	case domain.DriverErrorTimeout:
+		m.started = time.Time{}
+		m.timeouts++
+		limit := m.maxTimeouts
+		if limit <= 0 {
+			limit = defaultMaxTimeouts
+		}
+		if m.timeouts >= limit {
+			m.timeouts = 0
+			return true
+		}
+	case domain.DriverErrorConnectionLost:
+		m.started = time.Time{}
+		m.timeouts = 0
+		return true
+	default:
+		// the device answered
+		m.responded()
+		m.timeouts = 0
+	}
+	return false
+}
+
+// Succeeded records a request which got a response
+func (m *Monitor) Succeeded() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.requests++
+	m.responded()
+	m.timeouts = 0
+}
+
+func (m *Monitor) responded() {
+	m.responses++
+	if m.started.IsZero() {
+		return
+	}
+	elapsed := time.Since(m.started)
+	m.started = time.Time{}
+	if len(m.responseTimes) < responseSamples {
+		m.responseTimes = append(m.responseTimes, elapsed)
+		return
+	}
+	m.responseTimes[m.next] = elapsed
+	m.next = (m.next + 1) % responseSamples
+}
+
+// Health a snapshot of the connection, the response times are of the latest requests
+func (m *Monitor) Health() domain.PortHealth {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	health := domain.PortHealth{
+		Connected:           m.connected,
+		LastConnectTime:     m.lastConnectTime,
+		ReconnectCount:      m.reconnectCount,
+		ConsecutiveTimeouts: m.timeouts,
+		LastErrorTime:       m.lastErrorTime,
+		Requests:            m.requests,
+		Responses:           m.responses,
+	}
+	if m.lastError != nil {
+		health.LastError = m.lastError.Error()
+	}
+	if len(m.responseTimes) > 0 {
+		sorted := append([]time.Duration(nil), m.responseTimes...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		})
+		var total time.Duration
+		for _, single := range sorted {
+			total += single
+		}
+		health.AvgResponseMs = milliseconds(total / time.Duration(len(sorted)))
+		health.P95ResponseMs = milliseconds(sorted[(len(sorted)*95+99)/100-1])
+	}
+	return health
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,117 @@
+package connection
+
+import (
+	"didaGatewayCenter/domain"
+	"go.uber.org/zap"
+	"math/rand"
+	"time"
+)
+
+// the defaults of the reconnect policy, used for the values not set in the port config
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultJitter         = 0.2
+	defaultDialTimeout    = 5 * time.Second
+	defaultMaxTimeouts    = 5
+)
+
+// Policy how a port retries a lost connection
+type Policy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter part of the delay randomly added or removed, 0 to 1
+	Jitter      float64
+	DialTimeout time.Duration
+	// MaxTimeouts consecutive timeouts after which the connection is reopened
+	MaxTimeouts int
+}
+
+// NewPolicy the reconnect policy of a port, the values missing in param are the defaults
+func NewPolicy(param domain.ReconnectParam) Policy {
+	p := Policy{
+		InitialBackoff: time.Duration(param.InitialBackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(param.MaxBackoffMs) * time.Millisecond,
+		Jitter:         float64(param.JitterPercent) / 100,
+		DialTimeout:    time.Duration(param.DialTimeoutMs) * time.Millisecond,
+		MaxTimeouts:    param.MaxTimeouts,
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	switch {
+	case param.JitterPercent == 0:
+		p.Jitter = defaultJitter
+	case p.Jitter < 0:
+		p.Jitter = 0
+	case p.Jitter > 1:
+		p.Jitter = 1
+	}
+	if p.DialTimeout <= 0 {
+		p.DialTimeout = defaultDialTimeout
+	}
+	if p.MaxTimeouts <= 0 {
+		p.MaxTimeouts = defaultMaxTimeouts
+	}
+	return p
+}
+
+// Backoff the delays between the connection attempts of a reconnect loop, it is not safe for concurrent use
+type Backoff struct {
+	policy   Policy
+	delay    time.Duration
+	attempts int
+}
+
+// NewBackoff starts at the initial backoff of policy
+func NewBackoff(policy Policy) *Backoff {
+	return &Backoff{policy: policy}
+}
+
+// Next counts a failed attempt and returns the delay before the next one, the delay doubles up to the max backoff,
+// the jitter never exceeds it
+func (b *Backoff) Next() time.Duration {
+	b.attempts++
+	if b.delay == 0 {
+		b.delay = b.policy.InitialBackoff
+	} else if b.delay < b.policy.MaxBackoff {
+		b.delay *= 2
+		if b.delay > b.policy.MaxBackoff {
+			b.delay = b.policy.MaxBackoff
+		}
+	}
+	if b.policy.Jitter <= 0 {
+		return b.delay
+	}
+	spread := float64(b.delay) * b.policy.Jitter
+	delay := b.delay + time.Duration((rand.Float64()*2-1)*spread)
+	if delay > b.policy.MaxBackoff {
+		delay = b.policy.MaxBackoff
+	}
+	return delay
+}
+
+// Wait logs the failed attempt with the delay before the next one and sleeps the delay
+func (b *Backoff) Wait(logger *zap.Logger, msg string, err error, fields ...zap.Field) {
+	delay := b.Next()
+	fields = append(fields, zap.Int("attempt", b.attempts), zap.Duration("retryIn", delay), zap.Error(err))
+	logger.Warn(msg, fields...)
+	time.Sleep(delay)
+}
+
+// Attempts the failed attempts since the last Reset
+func (b *Backoff) Attempts() int {
+	return b.attempts
+}
+
+// Reset the connection is open, the next failure starts again at the initial backoff
+func (b *Backoff) Reset() {
+	b.delay = 0
+	b.attempts = 0
+}
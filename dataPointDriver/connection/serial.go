@@ -0,0 +1,41 @@
+package connection
+
+import (
+	"didaGatewayCenter/dataPointConfig/usecase"
+	"didaGatewayCenter/domain"
+	"github.com/goburrow/serial"
+	"go.uber.org/zap"
+	"time"
+)
+
+// SerialConfig the serial config of a port, a read times out once the port is idle for timeout
+func SerialConfig(param domain.PortParam, timeout time.Duration) serial.Config {
+	parity := "N"
+	if param.Parity != "" {
+		parity = string(param.Parity[0])
+	}
+	return serial.Config{
+		Address:  usecase.ConvertComToDeviceNode(param.COM),
+		BaudRate: param.BandRate,
+		DataBits: param.DateBits,
+		StopBits: param.StopBit,
+		Parity:   parity,
+		Timeout:  timeout,
+	}
+}
+
+// OpenSerial opens the serial port of portConfig, a port which cannot be opened is retried with the backoff of
+// policy until it opens
+func OpenSerial(portConfig *domain.DataPointPortConfig, timeout time.Duration, policy Policy, logger *zap.Logger) serial.Port {
+	c := SerialConfig(portConfig.Param, timeout)
+	backoff := NewBackoff(policy)
+	for {
+		port, err := serial.Open(&c)
+		if err == nil {
+			logger.Info("serial port opened", zap.String("portName", portConfig.PortName), zap.String("node", c.Address),
+				zap.Int("attempts", backoff.Attempts()+1))
+			return port
+		}
+		backoff.Wait(logger, "Failed to open serial port", err, zap.String("portName", portConfig.PortName), zap.String("node", c.Address))
+	}
+}
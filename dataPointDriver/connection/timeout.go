@@ -0,0 +1,18 @@
+package connection
+
+import (
+	"didaGatewayCenter/domain"
+	"time"
+)
+
+// DefaultRespTimeout the response timeout of the ports without RespTimeOutMs
+const DefaultRespTimeout = time.Second
+
+// RespTimeout how long a device of the port has to answer a request, DefaultRespTimeout when it is not set
+func RespTimeout(param domain.PortParam) time.Duration {
+	timeout := time.Duration(param.RespTimeOutMs) * time.Millisecond
+	if timeout <= 0 {
+		return DefaultRespTimeout
+	}
+	return timeout
+}
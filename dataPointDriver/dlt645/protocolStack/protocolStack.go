@@ -0,0 +1,291 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Version int
+type controlCode byte
+
+const (
+	Version1997 Version = 1997
+	Version2007 Version = 2007
+)
+
+const (
+	frameStart byte = 0x68
+	frameEnd   byte = 0x16
+	wakeUp     byte = 0xFE
+	dataOffset byte = 0x33
+
+	controlRead1997        controlCode = 0x01
+	controlRead2007        controlCode = 0x11
+	controlReadAddress2007 controlCode = 0x13
+	controlResponse        controlCode = 0x80 // set in every frame sent by the meter
+	controlAbnormal        controlCode = 0x40 // set when the meter rejects the request
+	controlFollowUp        controlCode = 0x20 // more data follows in subsequent frames
+)
+
+var (
+	// BroadcastAddress2007 only the read address command may be sent to it
+	BroadcastAddress2007 = []byte{0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA}
+	// BroadcastAddress1997 answered by every meter on the bus
+	BroadcastAddress1997 = []byte{0x99, 0x99, 0x99, 0x99, 0x99, 0x99}
+	// DIMeterAddress1997 1997 has no read address command, the meter number is read instead
+	DIMeterAddress1997 = "C032"
+)
+
+var preamble = []byte{wakeUp, wakeUp, wakeUp, wakeUp}
+
+var errorMessage2007 = []string{
+	"Other error",
+	"No requested data",
+	"Password error or unauthorized",
+	"Communication rate cannot be changed",
+	"Yearly time zone exceeded",
+	"Daily time slot exceeded",
+	"Tariff number exceeded",
+}
+
+// DataFormat decimal places of the value and whether the highest bit is the sign
+type DataFormat struct {
+	Decimal int
+	Signed  bool
+}
+
+// dataFormats are matched by the longest prefix of the data identifier
+var dataFormats = map[string]DataFormat{
+	// DL/T 645-2007
+	"00":       {2, false}, // energy XXXXXX.XX kWh
+	"0201":     {1, false}, // voltage XXX.X V
+	"0202":     {3, true},  // current XXX.XXX A
+	"0203":     {4, true},  // active power XX.XXXX kW
+	"0204":     {4, true},  // reactive power XX.XXXX kvar
+	"0205":     {4, true},  // apparent power XX.XXXX kVA
+	"0206":     {3, true},  // power factor X.XXX
+	"0207":     {1, false}, // phase angle XXX.X
+	"02800001": {3, true},  // neutral current XXX.XXX A
+	"02800002": {2, false}, // frequency XX.XX Hz
+	"02800007": {1, true},  // temperature XXX.X
+	// DL/T 645-1997
+	"90":  {2, false}, // energy XXXXXX.XX kWh
+	"91":  {2, false}, // reactive energy XXXXXX.XX kvarh
+	"B61": {0, false}, // voltage XXX V
+	"B62": {2, false}, // current XX.XX A
+	"B63": {4, false}, // active power XX.XXXX kW
+	"B64": {2, false}, // reactive power XX.XX kvar
+	"B65": {3, false}, // power factor X.XXX
+}
+
+type Dlt645 struct {
+	version Version
+	di      []byte
+}
+
+func NewDlt645() *Dlt645 {
+	d := Dlt645{}
+	return &d
+}
+
+// ParseAddress converts a 12-digit meter address into the 6 bytes sent on the wire, lowest byte first
+func ParseAddress(meterAddr string) ([]byte, error) {
+	if len(meterAddr) > 12 {
+		return nil, fmt.Errorf("meter address %s is longer than 12 digits", meterAddr)
+	}
+	meterAddr = strings.Repeat("0", 12-len(meterAddr)) + meterAddr
+	address, err := hex.DecodeString(meterAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid meter address %s: %v", meterAddr, err)
+	}
+	reverse(address)
+	return address, nil
+}
+
+// FormatAddress converts the address of a frame back into 12 digits
+func FormatAddress(address []byte) string {
+	r := make([]byte, len(address))
+	copy(r, address)
+	reverse(r)
+	return fmt.Sprintf("%X", r)
+}
+
+// GetDataFormat returns the format of the data identifier, unknown identifiers are treated as unsigned integers
+func GetDataFormat(di string) DataFormat {
+	di = strings.ToUpper(di)
+	format := DataFormat{}
+	matched := 0
+	for prefix, singleFormat := range dataFormats {
+		if len(prefix) > matched && strings.HasPrefix(di, prefix) &&
+			(len(di) == 8) == isPrefix2007(prefix) {
+			format = singleFormat
+			matched = len(prefix)
+		}
+	}
+	return format
+}
+
+// ReadVar builds a read data frame, the protocol version is chosen by the length of the data identifier
+func (d *Dlt645) ReadVar(address []byte, di string) ([]byte, error) {
+	diByte, err := hex.DecodeString(di)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data identifier %s: %v", di, err)
+	}
+	control := controlRead2007
+	switch len(diByte) {
+	case 4:
+		d.version = Version2007
+	case 2:
+		d.version = Version1997
+		control = controlRead1997
+	default:
+		return nil, fmt.Errorf("invalid length of data identifier %s", di)
+	}
+	reverse(diByte)
+	d.di = diByte
+	return frame(address, control, diByte), nil
+}
+
+// ReadAddress builds the broadcast read address frame of DL/T 645-2007
+func (d *Dlt645) ReadAddress() []byte {
+	d.version = Version2007
+	d.di = nil
+	return frame(BroadcastAddress2007, controlReadAddress2007, nil)
+}
+
+// Parse returns the address of the meter and the data of the response with the data identifier removed,
+// the data is converted to the highest byte first
+func (d *Dlt645) Parse(input []byte) ([]byte, []byte, error) {
+	start := bytes.IndexByte(input, frameStart)
+	if start < 0 {
+		return nil, nil, fmt.Errorf("no frame start found: % X", input)
+	}
+	input = input[start:]
+	if len(input) < 12 {
+		return nil, nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if input[7] != frameStart {
+		return nil, nil, fmt.Errorf("invalid frame: % X", input)
+	}
+	length := int(input[9])
+	if len(input) < 12+length {
+		return nil, nil, fmt.Errorf("incomplete data,require %d but got %d", 12+length, len(input))
+	}
+	if input[11+length] != frameEnd {
+		return nil, nil, fmt.Errorf("invalid frame end: %X", input[11+length])
+	}
+	if cs := checkSum(input[:10+length]); cs != input[10+length] {
+		return nil, nil, fmt.Errorf("checksum is %X not match %X which received", cs, input[10+length])
+	}
+	address := input[1:7]
+	control := controlCode(input[8])
+	data := make([]byte, length)
+	for index, single := range input[10 : 10+length] {
+		data[index] = single - dataOffset
+	}
+	if control&controlResponse == 0 {
+		return nil, nil, fmt.Errorf("not a response frame, control code: %X", control)
+	}
+	if control&controlAbnormal != 0 {
+		if len(data) == 0 {
+			return nil, nil, fmt.Errorf("abnormal response without error code")
+		}
+		return nil, nil, d.errorCode(data[0])
+	}
+	if control&controlFollowUp != 0 {
+		return nil, nil, fmt.Errorf("follow-up frames are not supported")
+	}
+	if len(data) < len(d.di) || !bytes.Equal(data[:len(d.di)], d.di) {
+		return nil, nil, fmt.Errorf("data identifier of the response % X does not match % X", data, d.di)
+	}
+	value := data[len(d.di):]
+	reverse(value)
+	return address, value, nil
+}
+
+// DecodeBCD converts the value with the highest byte first into a float,
+// the highest bit is the sign when signed is set
+func DecodeBCD(input []byte, format DataFormat) (float64, error) {
+	if len(input) == 0 {
+		return 0, fmt.Errorf("no any data")
+	}
+	data := make([]byte, len(input))
+	copy(data, input)
+	negative := false
+	if format.Signed && data[0]&0x80 != 0 {
+		negative = true
+		data[0] &= 0x7F
+	}
+	value := float64(0)
+	for _, single := range data {
+		high, low := single>>4, single&0x0F
+		if high > 9 || low > 9 {
+			return 0, fmt.Errorf("invalid bcd data: % X", input)
+		}
+		value = value*100 + float64(high)*10 + float64(low)
+	}
+	value /= math.Pow10(format.Decimal)
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// ErrorCodeError the meter rejected the request with the error code of an abnormal response
+type ErrorCodeError struct {
+	Code    byte
+	Message string
+}
+
+func (e ErrorCodeError) Error() string {
+	return e.Message
+}
+
+func (d *Dlt645) errorCode(code byte) error {
+	if d.version == Version1997 {
+		return ErrorCodeError{Code: code, Message: fmt.Sprintf("meter rejected the request, error code: %X", code)}
+	}
+	var messages []string
+	for index, message := range errorMessage2007 {
+		if code&(1<<index) != 0 {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) == 0 {
+		return ErrorCodeError{Code: code, Message: fmt.Sprintf("meter rejected the request, error code: %X", code)}
+	}
+	return ErrorCodeError{Code: code, Message: fmt.Sprintf("%s(%X)", strings.Join(messages, ","), code)}
+}
+
+// frame FE FE FE FE 68 A0..A5 68 C L DATA CS 16, every data byte is sent plus 0x33
+func frame(address []byte, control controlCode, data []byte) []byte {
+	result := []byte{frameStart}
+	result = append(result, address...)
+	result = append(result, frameStart, byte(control), byte(len(data)))
+	for _, single := range data {
+		result = append(result, single+dataOffset)
+	}
+	result = append(result, checkSum(result), frameEnd)
+	return append(append([]byte{}, preamble...), result...)
+}
+
+func checkSum(input []byte) byte {
+	sum := byte(0)
+	for _, single := range input {
+		sum += single
+	}
+	return sum
+}
+
+func isPrefix2007(prefix string) bool {
+	return !strings.HasPrefix(prefix, "9") && !strings.HasPrefix(prefix, "B")
+}
+
+func reverse(input []byte) {
+	for i, j := 0, len(input)-1; i < j; i, j = i+1, j-1 {
+		input[i], input[j] = input[j], input[i]
+	}
+}
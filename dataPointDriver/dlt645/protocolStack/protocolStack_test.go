@@ -0,0 +1,183 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func TestReadVar(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		di      string
+		want    string
+		wantErr bool
+	}{
+		{"2007 total active energy by broadcast", "AAAAAAAAAAAA", "00000000", "FEFEFEFE 68 AAAAAAAAAAAA 68 11 04 33333333 AD 16", false},
+		{"2007 total active energy", "000000000001", "00000000", "FEFEFEFE 68 010000000000 68 11 04 33333333 B2 16", false},
+		{"1997 total active energy", "000000000001", "9010", "FEFEFEFE 68 010000000000 68 01 02 43C3 DA 16", false},
+		{"odd data identifier", "000000000001", "901", "", true},
+		{"data identifier of 3 bytes", "000000000001", "020101", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := ParseAddress(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewDlt645().ReadVar(address, tt.di)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadVar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("ReadVar() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAddress(t *testing.T) {
+	want := decodeHex(t, "FEFEFEFE 68 AAAAAAAAAAAA 68 13 00 DF 16")
+	d := NewDlt645()
+	if got := d.ReadAddress(); !bytes.Equal(got, want) {
+		t.Fatalf("ReadAddress() = % X, want % X", got, want)
+	}
+	address, _, err := d.Parse(decodeHex(t, "68 010000000000 68 93 06 343333333333 9D 16"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := FormatAddress(address); got != "000000000001" {
+		t.Fatalf("FormatAddress() = %s, want 000000000001", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		di       string
+		response string
+		want     float64
+		wantCode byte
+		wantErr  bool
+	}{
+		{
+			name: "2007 energy", di: "00000000",
+			response: "FEFE 68 010000000000 68 91 08 33333333 78563433 6B 16", want: 123.45,
+		},
+		{
+			name: "2007 negative current", di: "02020100",
+			response: "68 010000000000 68 91 07 33343535 8334B3 A4 16", want: -0.15,
+		},
+		{
+			name: "1997 energy", di: "9010",
+			response: "68 010000000000 68 81 04 43C3 7856 2A 16", want: 23.45,
+		},
+		{
+			name: "2007 no requested data", di: "00000000",
+			response: "68 010000000000 68 D1 01 35 D8 16", wantCode: 0x02, wantErr: true,
+		},
+		{
+			name: "checksum", di: "00000000",
+			response: "68 010000000000 68 91 08 33333333 78563433 6C 16", wantErr: true,
+		},
+		{
+			name: "other data identifier", di: "00010000",
+			response: "68 010000000000 68 91 08 33333333 78563433 6B 16", wantErr: true,
+		},
+		{
+			name: "incomplete", di: "00000000",
+			response: "68 010000000000 68 91 08 33333333 7856", wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDlt645()
+			address, _ := ParseAddress("000000000001")
+			if _, err := d.ReadVar(address, tt.di); err != nil {
+				t.Fatal(err)
+			}
+			_, data, err := d.Parse(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCode != 0 {
+				var errorCodeErr ErrorCodeError
+				if !errors.As(err, &errorCodeErr) || errorCodeErr.Code != tt.wantCode {
+					t.Fatalf("Parse() error = %v, want error code %X", err, tt.wantCode)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := DecodeBCD(data, GetDataFormat(tt.di))
+			if err != nil {
+				t.Fatalf("DecodeBCD() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("DecodeBCD() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBCD(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  DataFormat
+		want    float64
+		wantErr bool
+	}{
+		{"voltage", "2202", DataFormat{Decimal: 1}, 220.2, false},
+		{"unsigned keeps the highest bit", "80000100", DataFormat{Decimal: 4}, 8000.01, false},
+		{"signed power", "800001", DataFormat{Decimal: 4, Signed: true}, -0.0001, false},
+		{"invalid digit", "1A", DataFormat{}, 0, true},
+		{"empty", "", DataFormat{}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeBCD(decodeHex(t, tt.input), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeBCD() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("DecodeBCD() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDataFormat(t *testing.T) {
+	tests := []struct {
+		di   string
+		want DataFormat
+	}{
+		{"00010000", DataFormat{Decimal: 2}},
+		{"02010100", DataFormat{Decimal: 1}},
+		{"02020100", DataFormat{Decimal: 3, Signed: true}},
+		{"02800002", DataFormat{Decimal: 2}},
+		{"9010", DataFormat{Decimal: 2}},
+		{"B611", DataFormat{}},
+		{"b621", DataFormat{Decimal: 2}},
+		{"04000401", DataFormat{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.di, func(t *testing.T) {
+			if got := GetDataFormat(tt.di); got != tt.want {
+				t.Fatalf("GetDataFormat(%s) = %+v, want %+v", tt.di, got, tt.want)
+			}
+		})
+	}
+}
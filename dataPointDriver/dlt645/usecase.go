@@ -0,0 +1,209 @@
+package dlt645
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataPointDriver/dlt645/protocolStack"
+	"didaGatewayCenter/domain"
+	"didaGatewayCenter/net"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"sync"
+	"time"
+)
+
+type dlt645 struct {
+	d            *protocolStack.Dlt645
+	isConnected  bool
+	iLogU        domain.ILogUsecase
+	iDTU         domain.IDataTransformUsecase
+	conn         domain.Software
+	portConfig   *domain.DataPointPortConfig
+	meterAddress map[string][]byte // meter address of every device, found by broadcast when not configured
+	monitor      connection.Monitor
+	policy       connection.Policy
+	lock         sync.Mutex
+}
+
+func (d *dlt645) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
+	d.portConfig = portConfig
+	d.iDTU = transform
+	d.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	d.monitor.SetMaxTimeouts(d.policy.MaxTimeouts)
+
+	go func() {
+		d.connect()
+	}()
+
+	return
+}
+
+func (d *dlt645) connect() {
+	d.iLogU.GetLogger().Info("dlt645 meter is connecting", zap.String("portName", d.portConfig.PortName))
+	for {
+		if d.isConnected {
+			time.Sleep(time.Second)
+			continue
+		}
+		// the port is reopened after too many timeouts in a row
+		d.lock.Lock()
+		net.Close(d.conn)
+		d.lock.Unlock()
+		port := connection.OpenSerial(d.portConfig, time.Millisecond*50, d.policy, d.iLogU.GetLogger())
+		d.lock.Lock()
+		d.conn = net.New(port)
+		d.lock.Unlock()
+		d.isConnected = true
+		d.monitor.Connected()
+	}
+}
+
+func (d *dlt645) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
+	if !d.isConnected {
+		time.Sleep(time.Second)
+		return nil, connection.ErrNotConnected
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	timeout := connection.RespTimeout(portInfo.Param)
+
+	d.monitor.Begin()
+	address, err := d.getAddress(deviceInfo, timeout)
+	if err != nil {
+		d.iLogU.GetLogger().Warn("cannot get the meter address", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.Error(err))
+		return nil, d.checkError(err)
+	}
+	bb, err := d.d.ReadVar(address, variableList.Param.DI)
+	if err != nil {
+		d.iLogU.GetLogger().Warn("invalid variable config", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidAddress("%v", err)
+	}
+	r, err := d.conn.WriteReadTimeout(bb, timeout)
+	if err != nil {
+		driverErr := d.checkError(err)
+		d.iLogU.GetLogger().Warn("read from dlt645 meter failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(driverErr))
+		return nil, driverErr
+	}
+	_, valueByte, err := d.d.Parse(r)
+	if err != nil {
+		d.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name),
+			zap.String("result", fmt.Sprintf("% X", r)), zap.Error(err))
+		return nil, d.checkError(err)
+	}
+	d.monitor.Succeeded()
+	v, err := protocolStack.DecodeBCD(valueByte, protocolStack.GetDataFormat(variableList.Param.DI))
+	if err != nil {
+		d.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
+	}
+
+	// the decoded value is passed through as a double so that modulus, offset and decimal still apply
+	tempDevice := *deviceInfo
+	tempDevice.DoubleOrder = domain.ByteOrderABCD
+	tempVariable := *variableList
+	tempVariable.DataType = domain.VarDataTypeDouble
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, math.Float64bits(v))
+	value, err := d.iDTU.ByteToValue(&tempDevice, &tempVariable, result)
+	if err != nil {
+		d.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
+	}
+	return value, nil
+}
+
+func (d *dlt645) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, value interface{}) error {
+	return connection.InvalidAddress("writing dlt645 meter is not supported")
+}
+
+// Transmit sends a raw frame on the serial bus between the requests of the cycle sample
+func (d *dlt645) Transmit(request []byte, timeout time.Duration) ([]byte, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.isConnected {
+		return nil, connection.ErrNotConnected
+	}
+	return d.conn.WriteReadTimeout(request, timeout)
+}
+
+// Health the health of the connection of the port
+func (d *dlt645) Health() domain.PortHealth {
+	return d.monitor.Health()
+}
+
+// checkError classifies the error of a request, the abnormal responses are exceptions of the meter, the serial port
+// is reopened when the meters time out too often
+func (d *dlt645) checkError(err error) error {
+	var errorCodeErr protocolStack.ErrorCodeError
+	if errors.As(err, &errorCodeErr) {
+		err = connection.Exception(int(errorCodeErr.Code), err)
+	}
+	driverErr := connection.Classify(err)
+	if d.monitor.Failed(driverErr) {
+		d.isConnected = false
+		d.monitor.Disconnected()
+		d.iLogU.GetLogger().Warn("dlt645 meters not answering, reopening the serial port", zap.String("portName", d.portConfig.PortName),
+			zap.Error(driverErr))
+	}
+	return driverErr
+}
+
+// getAddress returns the configured meter address, or discovers it by broadcast when the device has none.
+// Broadcast discovery only works when the meter is the only one on the bus
+func (d *dlt645) getAddress(deviceInfo *domain.DeviceList, timeout time.Duration) ([]byte, error) {
+	if address, ok := d.meterAddress[deviceInfo.DevName]; ok {
+		return address, nil
+	}
+	if deviceInfo.MeterAddr != "" {
+		address, err := protocolStack.ParseAddress(deviceInfo.MeterAddr)
+		if err != nil {
+			return nil, err
+		}
+		d.meterAddress[deviceInfo.DevName] = address
+		return address, nil
+	}
+	r, err := d.conn.WriteReadTimeout(d.d.ReadAddress(), timeout)
+	if err == nil {
+		if address, _, err := d.d.Parse(r); err == nil {
+			d.discovered(deviceInfo, address)
+			return address, nil
+		}
+	}
+	// DL/T 645-1997 meters do not know the read address command
+	bb, _ := d.d.ReadVar(protocolStack.BroadcastAddress1997, protocolStack.DIMeterAddress1997)
+	r, err = d.conn.WriteReadTimeout(bb, timeout)
+	if err != nil {
+		return nil, err
+	}
+	address, _, err := d.d.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	d.discovered(deviceInfo, address)
+	return address, nil
+}
+
+func (d *dlt645) discovered(deviceInfo *domain.DeviceList, address []byte) {
+	tempAddress := make([]byte, len(address))
+	copy(tempAddress, address)
+	d.meterAddress[deviceInfo.DevName] = tempAddress
+	d.iLogU.GetLogger().Info("dlt645 meter address discovered", zap.String("portName", d.portConfig.PortName),
+		zap.String("deviceName", deviceInfo.DevName), zap.String("meterAddr", protocolStack.FormatAddress(tempAddress)))
+}
+
+func NewDlt645Driver(iLogU domain.ILogUsecase) domain.IDataPointDriverUsecase {
+	d := dlt645{
+		iLogU:        iLogU,
+		d:            protocolStack.NewDlt645(),
+		meterAddress: make(map[string][]byte),
+	}
+	return &d
+}
@@ -0,0 +1,221 @@
+package modbus
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataTransform"
+	"didaGatewayCenter/domain"
+	"fmt"
+	"github.com/HarryChen001/go-modbus"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+const (
+	maxReadRegisters = 125
+	maxReadBits      = 2000
+)
+
+// blockItem the position of a variable in the registers or coils of its function code
+type blockItem struct {
+	index   int
+	address int
+	count   int
+}
+
+// block one read request covering several variables of the same function code
+type block struct {
+	functionCode byte
+	address      int
+	count        int
+	items        []blockItem
+}
+
+// ReadBlock groups the variables of a device into block reads, the variables which cannot be planned are read
+// separately
+func (m *modbusDriver) ReadBlock(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList []*domain.DataPointVariableList) ([]domain.IValueType, []error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	values := make([]domain.IValueType, len(variableList))
+	errs := make([]error, len(variableList))
+	if !m.isConnected {
+		time.Sleep(time.Second)
+		for index := range errs {
+			errs[index] = connection.ErrNotConnected
+		}
+		return values, errs
+	}
+	blocks, singles := planBlocks(variableList, portInfo.Param.MaxReadGap)
+	m.setSlaveId(portInfo, uint8(deviceInfo.DevAddr))
+	for _, singleBlock := range blocks {
+		if !m.isConnected {
+			setError(errs, singleBlock.items, connection.ErrNotConnected)
+			continue
+		}
+		m.monitor.Begin()
+		result, err := m.readBlock(singleBlock)
+		if err != nil {
+			setError(errs, singleBlock.items, m.checkError(portInfo, deviceInfo, variableList[singleBlock.items[0].index].Name, err))
+			continue
+		}
+		m.monitor.Succeeded()
+		for _, item := range singleBlock.items {
+			variable := variableList[item.index]
+			data, ok := singleBlock.slice(result, item)
+			if !ok {
+				m.iLogU.GetLogger().Warn("incomplete block read response", zap.String("portName", portInfo.PortName),
+					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variable.Name),
+					zap.Int("address", singleBlock.address), zap.Int("count", singleBlock.count), zap.Int("length", len(result)))
+				errs[item.index] = connection.InvalidResponse(fmt.Errorf("incomplete block read response of %d bytes", len(result)))
+				continue
+			}
+			value, err := m.dataTransform.ByteToValue(deviceInfo, variable, data)
+			if err != nil {
+				m.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variable.Name), zap.Error(err))
+				errs[item.index] = connection.InvalidResponse(err)
+				continue
+			}
+			values[item.index] = value
+		}
+	}
+	for _, index := range singles {
+		if !m.isConnected {
+			errs[index] = connection.ErrNotConnected
+			continue
+		}
+		values[index], errs[index] = m.read(portInfo, deviceInfo, variableList[index])
+	}
+	return values, errs
+}
+
+func setError(errs []error, items []blockItem, err error) {
+	for _, item := range items {
+		errs[item.index] = err
+	}
+}
+
+func (m *modbusDriver) readBlock(b *block) ([]byte, error) {
+	address, count := uint16(b.address), uint16(b.count)
+	switch b.functionCode {
+	case modbus.FuncCodeReadCoils:
+		return m.modbusClient.ReadCoils(address, count)
+	case modbus.FuncCodeReadDiscreteInputs:
+		return m.modbusClient.ReadDiscreteInputs(address, count)
+	case modbus.FuncCodeReadHoldingRegisters:
+		return m.modbusClient.ReadHoldingRegisters(address, count)
+	}
+	return m.modbusClient.ReadInputRegisters(address, count)
+}
+
+// slice the bytes of a variable in the response, bits are packed into bytes from the lowest bit like a single read
+func (b *block) slice(result []byte, item blockItem) ([]byte, bool) {
+	offset := item.address - b.address
+	if b.functionCode == modbus.FuncCodeReadHoldingRegisters || b.functionCode == modbus.FuncCodeReadInputRegisters {
+		if len(result) < (offset+item.count)*2 {
+			return nil, false
+		}
+		return result[offset*2 : (offset+item.count)*2], true
+	}
+	if len(result)*8 < offset+item.count {
+		return nil, false
+	}
+	data := make([]byte, (item.count+7)/8)
+	for i := 0; i < item.count; i++ {
+		bit := offset + i
+		if result[bit/8]&(1<<(bit%8)) != 0 {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	if len(data) == 1 {
+		data = []byte{0, data[0]}
+	}
+	return data, true
+}
+
+// planBlocks sorts the variables by function code and address, and merges the neighbours whose distance is not more
+// than maxGap as long as the block fits in one request
+func planBlocks(variableList []*domain.DataPointVariableList, maxGap int) ([]*block, []int) {
+	var (
+		items   = map[byte][]blockItem{}
+		singles []int
+		blocks  []*block
+	)
+	for index, variable := range variableList {
+		functionCode, address, count := readRange(variable)
+		if functionCode == 0 || maxGap < 0 {
+			singles = append(singles, index)
+			continue
+		}
+		items[functionCode] = append(items[functionCode], blockItem{index: index, address: address, count: count})
+	}
+	for _, functionCode := range []byte{modbus.FuncCodeReadCoils, modbus.FuncCodeReadDiscreteInputs, modbus.FuncCodeReadHoldingRegisters,
+		modbus.FuncCodeReadInputRegisters} {
+		list := items[functionCode]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].address < list[j].address })
+		maxCount := maxReadRegisters
+		if functionCode == modbus.FuncCodeReadCoils || functionCode == modbus.FuncCodeReadDiscreteInputs {
+			maxCount = maxReadBits
+		}
+		var current *block
+		for _, item := range list {
+			if current != nil {
+				end := current.address + current.count
+				newEnd := item.address + item.count
+				if newEnd < end {
+					newEnd = end
+				}
+				if item.address-end <= maxGap && newEnd-current.address <= maxCount {
+					current.count = newEnd - current.address
+					current.items = append(current.items, item)
+					continue
+				}
+			}
+			current = &block{functionCode: functionCode, address: item.address, count: item.count, items: []blockItem{item}}
+			blocks = append(blocks, current)
+		}
+	}
+	return blocks, singles
+}
+
+// readRange the function code, address and quantity a single read of the variable uses, function code 0 when the
+// variable cannot be read in a block
+func readRange(variable *domain.DataPointVariableList) (byte, int, int) {
+	dataType := variable.DataType
+	address := variable.Param.RegAddr
+	count := 0
+	switch dataType {
+	case domain.VarDataTypeBit, domain.VarDataTypeBool, domain.VarDataTypeUint16, domain.VarDataTypeInt16:
+		count = 1
+	case domain.VarDataTypeUint32, domain.VarDataTypeInt32, domain.VarDataTypeFloat:
+		count = 2
+	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
+		count = 4
+	case domain.VarDataTypeString:
+		count = (dataTransform.StringSize(variable) + 1) / 2
+		if count == 0 || count > maxReadRegisters {
+			return 0, 0, 0
+		}
+		switch variable.Param.RegType {
+		case domain.RegTypeHoldingRegisterWithWriteSingle, domain.RegTypeHoldingRegisterWithWriteMultiple, domain.RegTypeInputRegister:
+		default:
+			return 0, 0, 0
+		}
+	default:
+		return 0, 0, 0
+	}
+	switch variable.Param.RegType {
+	case domain.RegTypeCoilStatusWithWriteSingle, domain.RegTypeCoilStatusWithWriteMultiple:
+		if dataType == domain.VarDataTypeBit {
+			return modbus.FuncCodeReadCoils, address * 8, 8
+		}
+		return modbus.FuncCodeReadCoils, address, count
+	case domain.RegTypeInputStatus:
+		return modbus.FuncCodeReadDiscreteInputs, address, count
+	case domain.RegTypeHoldingRegisterWithWriteSingle, domain.RegTypeHoldingRegisterWithWriteMultiple:
+		return modbus.FuncCodeReadHoldingRegisters, address, count
+	case domain.RegTypeInputRegister:
+		return modbus.FuncCodeReadInputRegisters, address, count
+	}
+	return 0, 0, 0
+}
@@ -0,0 +1,149 @@
+package modbus
+
+import (
+	"bytes"
+	"didaGatewayCenter/domain"
+	"github.com/HarryChen001/go-modbus"
+	"reflect"
+	"testing"
+)
+
+func variable(regType domain.RegisterType, address int, dataType domain.DataType) *domain.DataPointVariableList {
+	v := &domain.DataPointVariableList{DataType: dataType}
+	v.Param.RegType = regType
+	v.Param.RegAddr = address
+	return v
+}
+
+func holding(address int, dataType domain.DataType) *domain.DataPointVariableList {
+	return variable(domain.RegTypeHoldingRegisterWithWriteMultiple, address, dataType)
+}
+
+func TestPlanBlocks(t *testing.T) {
+	str := holding(20, domain.VarDataTypeString)
+	str.Param.StrLength = 9
+	coilString := variable(domain.RegTypeCoilStatusWithWriteSingle, 0, domain.VarDataTypeString)
+	coilString.Param.StrLength = 4
+
+	tests := []struct {
+		name        string
+		variables   []*domain.DataPointVariableList
+		maxGap      int
+		wantBlocks  []*block
+		wantSingles []int
+	}{
+		{
+			name:      "neighbours are merged",
+			variables: []*domain.DataPointVariableList{holding(0, domain.VarDataTypeUint16), holding(1, domain.VarDataTypeFloat), holding(10, domain.VarDataTypeInt16)},
+			maxGap:    5,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 0, count: 3, items: []blockItem{{0, 0, 1}, {1, 1, 2}}},
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 10, count: 1, items: []blockItem{{2, 10, 1}}},
+			},
+		},
+		{
+			name:      "the gap is read",
+			variables: []*domain.DataPointVariableList{holding(0, domain.VarDataTypeUint16), holding(1, domain.VarDataTypeFloat), holding(10, domain.VarDataTypeInt16)},
+			maxGap:    7,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 0, count: 11, items: []blockItem{{0, 0, 1}, {1, 1, 2}, {2, 10, 1}}},
+			},
+		},
+		{
+			name:        "negative gap reads every variable alone",
+			variables:   []*domain.DataPointVariableList{holding(0, domain.VarDataTypeUint16), holding(1, domain.VarDataTypeUint16)},
+			maxGap:      -1,
+			wantSingles: []int{0, 1},
+		},
+		{
+			name:      "sorted by address",
+			variables: []*domain.DataPointVariableList{holding(20, domain.VarDataTypeUint16), holding(0, domain.VarDataTypeUint16), holding(10, domain.VarDataTypeUint16)},
+			maxGap:    10,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 0, count: 21, items: []blockItem{{1, 0, 1}, {2, 10, 1}, {0, 20, 1}}},
+			},
+		},
+		{
+			name:      "overlapping variables",
+			variables: []*domain.DataPointVariableList{holding(0, domain.VarDataTypeDouble), holding(1, domain.VarDataTypeUint16)},
+			maxGap:    0,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 0, count: 4, items: []blockItem{{0, 0, 4}, {1, 1, 1}}},
+			},
+		},
+		{
+			name:      "a request reads 125 registers at most",
+			variables: []*domain.DataPointVariableList{holding(0, domain.VarDataTypeUint16), holding(124, domain.VarDataTypeUint16), holding(125, domain.VarDataTypeUint16)},
+			maxGap:    200,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 0, count: 125, items: []blockItem{{0, 0, 1}, {1, 124, 1}}},
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 125, count: 1, items: []blockItem{{2, 125, 1}}},
+			},
+		},
+		{
+			name: "function codes are not mixed",
+			variables: []*domain.DataPointVariableList{
+				variable(domain.RegTypeInputRegister, 0, domain.VarDataTypeUint16),
+				holding(0, domain.VarDataTypeUint16),
+				variable(domain.RegTypeInputStatus, 1, domain.VarDataTypeBool),
+				variable(domain.RegTypeCoilStatusWithWriteSingle, 2, domain.VarDataTypeBit),
+			},
+			maxGap: 10,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadCoils, address: 16, count: 8, items: []blockItem{{3, 16, 8}}},
+				{functionCode: modbus.FuncCodeReadDiscreteInputs, address: 1, count: 1, items: []blockItem{{2, 1, 1}}},
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 0, count: 1, items: []blockItem{{1, 0, 1}}},
+				{functionCode: modbus.FuncCodeReadInputRegisters, address: 0, count: 1, items: []blockItem{{0, 0, 1}}},
+			},
+		},
+		{
+			name:      "strings of registers are blocks, strings of coils are not",
+			variables: []*domain.DataPointVariableList{str, coilString},
+			maxGap:    0,
+			wantBlocks: []*block{
+				{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 20, count: 5, items: []blockItem{{0, 20, 5}}},
+			},
+			wantSingles: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, singles := planBlocks(tt.variables, tt.maxGap)
+			if !reflect.DeepEqual(blocks, tt.wantBlocks) {
+				t.Fatalf("planBlocks() blocks = %+v, want %+v", blocks, tt.wantBlocks)
+			}
+			if !reflect.DeepEqual(singles, tt.wantSingles) {
+				t.Fatalf("planBlocks() singles = %v, want %v", singles, tt.wantSingles)
+			}
+		})
+	}
+}
+
+func TestBlockSlice(t *testing.T) {
+	registers := &block{functionCode: modbus.FuncCodeReadHoldingRegisters, address: 10, count: 3}
+	coils := &block{functionCode: modbus.FuncCodeReadCoils, address: 0, count: 16}
+	tests := []struct {
+		name   string
+		block  *block
+		result []byte
+		item   blockItem
+		want   []byte
+		wantOk bool
+	}{
+		{"register", registers, []byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x03}, blockItem{address: 11, count: 1}, []byte{0x00, 0x02}, true},
+		{"registers", registers, []byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x03}, blockItem{address: 11, count: 2}, []byte{0x00, 0x02, 0x00, 0x03}, true},
+		{"incomplete registers", registers, []byte{0x00, 0x01, 0x00, 0x02}, blockItem{address: 11, count: 2}, nil, false},
+		{"coil", coils, []byte{0xA0, 0x02}, blockItem{address: 5, count: 1}, []byte{0x00, 0x01}, true},
+		{"coil of the second byte", coils, []byte{0xA0, 0x02}, blockItem{address: 9, count: 1}, []byte{0x00, 0x01}, true},
+		{"8 coils across bytes", coils, []byte{0xA0, 0x02}, blockItem{address: 4, count: 8}, []byte{0x00, 0x2A}, true},
+		{"incomplete coils", coils, []byte{0xA0}, blockItem{address: 9, count: 1}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.block.slice(tt.result, tt.item)
+			if ok != tt.wantOk || !bytes.Equal(got, tt.want) {
+				t.Fatalf("slice() = % X, %v, want % X, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package modbus
+
+import (
+	"didaGatewayCenter/domain"
+	"encoding/binary"
+	"github.com/HarryChen001/go-modbus"
+	"net"
+	"time"
+)
+
+const (
+	rtuMinSize       = 4
+	rtuMaxSize       = 256
+	rtuExceptionSize = 5
+)
+
+// rtuNetTransporter sends raw RTU frames with CRC to a serial-to-Ethernet converter in transparent mode,
+// the frames are packed by the RTUClientHandler
+type rtuNetTransporter struct {
+	conn    net.Conn
+	timeout time.Duration
+	isUdp   bool
+}
+
+func newRtuNetTransporter(network string, address string, dialTimeout time.Duration, timeout time.Duration) (*rtuNetTransporter, error) {
+	conn, err := net.DialTimeout(network, address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	t := rtuNetTransporter{conn: conn, timeout: timeout, isUdp: network == "udp"}
+	return &t, nil
+}
+
+func (t *rtuNetTransporter) Send(aduRequest []byte) ([]byte, error) {
+	if err := t.conn.SetDeadline(time.Now().Add(t.timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := t.conn.Write(aduRequest); err != nil {
+		return nil, err
+	}
+	var data [rtuMaxSize]byte
+	// every datagram carries a whole frame
+	if t.isUdp {
+		n, err := t.conn.Read(data[:])
+		if err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	}
+	// the converter may split a frame into several segments
+	length := 0
+	bytesToRead := rtuResponseLength(aduRequest)
+	for length < bytesToRead {
+		n, err := t.conn.Read(data[length:])
+		if err != nil {
+			return nil, err
+		}
+		length += n
+		if length >= 2 && data[1] == aduRequest[1]|0x80 {
+			bytesToRead = rtuExceptionSize
+		}
+	}
+	return data[:length], nil
+}
+
+func (t *rtuNetTransporter) Close() error {
+	return t.conn.Close()
+}
+
+// rtuResponseLength expected length of the normal response of an RTU request
+func rtuResponseLength(adu []byte) int {
+	length := rtuMinSize
+	if len(adu) < 6 {
+		return length
+	}
+	count := int(binary.BigEndian.Uint16(adu[4:]))
+	switch adu[1] {
+	case modbus.FuncCodeReadDiscreteInputs, modbus.FuncCodeReadCoils:
+		length += 1 + (count+7)/8
+	case modbus.FuncCodeReadInputRegisters, modbus.FuncCodeReadHoldingRegisters, modbus.FuncCodeReadWriteMultipleRegisters:
+		length += 1 + count*2
+	case modbus.FuncCodeWriteSingleCoil, modbus.FuncCodeWriteMultipleCoils,
+		modbus.FuncCodeWriteSingleRegister, modbus.FuncCodeWriteMultipleRegisters:
+		length += 4
+	case modbus.FuncCodeMaskWriteRegister:
+		length += 6
+	}
+	if length > rtuMaxSize {
+		return rtuMaxSize
+	}
+	return length
+}
+
+// netModeName is used in logs only
+func netModeName(netMode domain.NetMode) string {
+	switch netMode {
+	case domain.NetModeRtuOverTcp:
+		return "rtu over tcp"
+	case domain.NetModeRtuOverUdp:
+		return "rtu over udp"
+	}
+	return "tcp"
+}
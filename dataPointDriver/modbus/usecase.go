@@ -1,50 +1,50 @@
 package modbus
 
 import (
-	"didaGatewayCenter/dataPointConfig/usecase"
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataTransform"
 	"didaGatewayCenter/domain"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/HarryChen001/go-modbus"
-	"github.com/goburrow/serial"
 	"go.uber.org/zap"
-	"net"
-	"os"
-	"reflect"
 	"sync"
-	"syscall"
 	"time"
 )
 
 type modbusDriver struct {
-	iLogU            domain.ILogUsecase
-	rtuClientHandler *modbus.RTUClientHandler
-	tcpClientHandler *modbus.TCPClientHandler
-	isConnected      bool
-	lock             sync.Mutex
-	modbusClient     modbus.Client
-	dataTransform    domain.IDataTransformUsecase
-	timeoutCount     int
+	iLogU              domain.ILogUsecase
+	rtuClientHandler   *modbus.RTUClientHandler
+	asciiClientHandler *modbus.ASCIIClientHandler
+	tcpClientHandler   *modbus.TCPClientHandler
+	rtuNetTransporter  *rtuNetTransporter
+	isConnected        bool
+	lock               sync.Mutex
+	modbusClient       modbus.Client
+	dataTransform      domain.IDataTransformUsecase
+	monitor            connection.Monitor
+	policy             connection.Policy
 }
 
-func (m *modbusDriver) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) domain.IValueType {
+func (m *modbusDriver) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	if !m.isConnected {
 		time.Sleep(time.Second)
-		return nil
+		return nil, connection.ErrNotConnected
 	}
+	return m.read(portInfo, deviceInfo, variableList)
+}
+
+func (m *modbusDriver) read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
 	slaveDeviceAddress := deviceInfo.DevAddr
 	dataType := variableList.DataType
 	regType := variableList.Param.RegType
 	regAddr := variableList.Param.RegAddr
 	length := uint16(0)
-	if portInfo.PortType == domain.NetType {
-		m.tcpClientHandler.SlaveId = uint8(slaveDeviceAddress)
-	} else {
-		m.rtuClientHandler.SlaveId = uint8(slaveDeviceAddress)
-	}
+	m.setSlaveId(portInfo, uint8(slaveDeviceAddress))
 	switch dataType {
 	case domain.VarDataTypeBit:
 		length = 1
@@ -54,9 +54,12 @@ func (m *modbusDriver) Read(portInfo *domain.DataPointPortConfig, deviceInfo *do
 		length = 2
 	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
 		length = 4
+	case domain.VarDataTypeString:
+		length = uint16((dataTransform.StringSize(variableList) + 1) / 2)
 	}
 	var result []byte
 	var err error
+	m.monitor.Begin()
 	switch regType {
 	case domain.RegTypeCoilStatusWithWriteSingle, domain.RegTypeCoilStatusWithWriteMultiple:
 		if dataType == domain.VarDataTypeBit {
@@ -70,41 +73,13 @@ func (m *modbusDriver) Read(portInfo *domain.DataPointPortConfig, deviceInfo *do
 		result, err = m.modbusClient.ReadHoldingRegisters(uint16(regAddr), length)
 	case domain.RegTypeInputRegister:
 		result, err = m.modbusClient.ReadInputRegisters(uint16(regAddr), length)
+	default:
+		return nil, connection.InvalidAddress("unsupported register type %d", regType)
 	}
 	if err != nil {
-		if errors.Is(err, os.ErrDeadlineExceeded) {
-			m.timeoutCount++
-			if m.timeoutCount == 5 {
-				m.timeoutCount = 0
-				m.isConnected = false
-				m.iLogU.GetLogger().Warn("modbus timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-			} else {
-				m.iLogU.GetLogger().Warn("read from modbus timeout", zap.String("portName", portInfo.PortName),
-					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-			}
-			return nil
-		}
-		if opErr, ok := err.(*net.OpError); ok {
-			switch t := opErr.Err.(type) {
-			case *os.SyscallError:
-				if errno, ok := t.Err.(syscall.Errno); ok {
-					switch errno {
-					case syscall.ECONNABORTED:
-						m.isConnected = false
-						m.iLogU.GetLogger().Warn("modbus connection aborted,reconnecting")
-					default:
-						m.iLogU.GetLogger().Warn("unexpected syscall error occurred while reading modbus", zap.String("portName", portInfo.PortName),
-							zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-					}
-				}
-			default:
-				m.iLogU.GetLogger().Warn("unknown operation error", zap.String("error type", reflect.TypeOf(opErr.Err).String()), zap.Error(err))
-			}
-		} else {
-			m.iLogU.GetLogger().Warn("error occurred while reading modbus", zap.String("portName", portInfo.PortName),
-				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-		}
+		return nil, m.checkError(portInfo, deviceInfo, variableList.Name, err)
 	}
+	m.monitor.Succeeded()
 	if len(result) == 1 {
 		result = []byte{0, result[0]}
 	}
@@ -112,31 +87,62 @@ func (m *modbusDriver) Read(portInfo *domain.DataPointPortConfig, deviceInfo *do
 	if err != nil {
 		m.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
 	}
 
-	return value
+	return value, nil
+}
+
+// checkError classifies and logs the error of a request, the connection or the serial port is reopened when it is lost or
+// times out too often
+func (m *modbusDriver) checkError(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableName string, err error) error {
+	var modbusErr *modbus.ModbusError
+	if errors.As(err, &modbusErr) {
+		err = connection.Exception(int(modbusErr.ExceptionCode), err)
+	}
+	driverErr := connection.Classify(err)
+	if m.monitor.Failed(driverErr) {
+		m.isConnected = false
+		m.monitor.Disconnected()
+		m.iLogU.GetLogger().Warn("modbus connection lost, reconnecting", zap.String("portName", portInfo.PortName), zap.Error(driverErr))
+		if portInfo.PortType == domain.SerialType {
+			handler, deviceNode := m.serialHandler()
+			_ = handler.Close()
+			go m.reopenSerial(portInfo.PortName, deviceNode, driverErr, handler.Connect)
+		}
+		return driverErr
+	}
+	m.iLogU.GetLogger().Warn("modbus request failed", zap.String("portName", portInfo.PortName),
+		zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableName), zap.Error(driverErr))
+	return driverErr
 }
 
 func (m *modbusDriver) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	if !m.isConnected {
-		time.Sleep(time.Second)
-		return nil
+		return connection.ErrNotConnected
 	}
 	slaveDeviceAddress := deviceInfo.DevAddr
 	dataType := variableInfo.DataType
 	regType := variableInfo.Param.RegType
 	regAddr := variableInfo.Param.RegAddr
 	length := uint16(0)
-	if portInfo.PortType == domain.NetType {
-		m.tcpClientHandler.SlaveId = uint8(slaveDeviceAddress)
-	} else {
-		m.rtuClientHandler.SlaveId = uint8(slaveDeviceAddress)
+	m.setSlaveId(portInfo, uint8(slaveDeviceAddress))
+	if dataType == domain.VarDataTypeString {
+		return m.writeString(portInfo, deviceInfo, variableInfo, inputValue)
 	}
-	value := (inputValue.(float64) - variableInfo.Offset) / variableInfo.Modulus
+	// the value is scaled by ValueToByte, only the word of a bit is computed here
+	value := inputValue
+	isSet := dataTransform.NewValue(inputValue).ToBool()
 	switch dataType {
 	case domain.VarDataTypeBit:
-		v := m.Read(portInfo, deviceInfo, variableInfo).OriginalValue()
-		if inputValue.(float64) != 0 {
+		current, err := m.read(portInfo, deviceInfo, variableInfo)
+		if err != nil {
+			return err
+		}
+		v := current.OriginalValue()
+		if isSet {
 			value = float64(v | (1 << variableInfo.Param.BitAddr))
 		} else {
 			value = float64(v & uint16(^(0x0001 << variableInfo.Param.BitAddr)))
@@ -150,65 +156,94 @@ func (m *modbusDriver) Write(portInfo *domain.DataPointPortConfig, deviceInfo *d
 		length = 4
 	}
 	result, err := m.dataTransform.ValueToByte(deviceInfo, variableInfo, value)
+	if err != nil {
+		return connection.InvalidAddress("%v", err)
+	}
 
+	m.monitor.Begin()
 	switch regType {
 	case domain.RegTypeCoilStatusWithWriteSingle:
 		temp := uint16(0)
-		if inputValue.(float64) != 0 {
+		if isSet {
 			temp = 0xff00
 		}
 		if dataType == domain.VarDataTypeBit {
 			regAddr = regAddr*8 + variableInfo.Param.BitAddr
 		}
-		result, err = m.modbusClient.WriteSingleCoil(uint16(regAddr), temp)
+		_, err = m.modbusClient.WriteSingleCoil(uint16(regAddr), temp)
 	case domain.RegTypeCoilStatusWithWriteMultiple:
 		if dataType == domain.VarDataTypeBit {
 			length = 8
 			regAddr *= 8
 		}
-		result, err = m.modbusClient.WriteMultipleCoils(uint16(regAddr), length, []byte{result[1]})
+		_, err = m.modbusClient.WriteMultipleCoils(uint16(regAddr), length, []byte{result[1]})
 	case domain.RegTypeHoldingRegisterWithWriteSingle:
-		result, err = m.modbusClient.WriteSingleRegister(uint16(regAddr), uint16(int16(value)))
+		if len(result) != 2 {
+			return connection.InvalidAddress("%s does not fit in a single register", variableInfo.Name)
+		}
+		_, err = m.modbusClient.WriteSingleRegister(uint16(regAddr), binary.BigEndian.Uint16(result))
 	case domain.RegTypeHoldingRegisterWithWriteMultiple:
-		result, err = m.modbusClient.WriteMultipleRegisters(uint16(regAddr), length, result)
+		_, err = m.modbusClient.WriteMultipleRegisters(uint16(regAddr), length, result)
+	default:
+		return connection.InvalidAddress("register type %d cannot be written", regType)
 	}
 	if err != nil {
-		if errors.Is(err, os.ErrDeadlineExceeded) {
-			m.timeoutCount++
-			if m.timeoutCount == 5 {
-				m.timeoutCount = 0
-				m.isConnected = false
-				m.iLogU.GetLogger().Warn("modbus timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-			} else {
-				m.iLogU.GetLogger().Warn("write modbus timeout", zap.String("portName", portInfo.PortName),
-					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-			}
-			return nil
-		}
-		if opErr, ok := err.(*net.OpError); ok {
-			switch t := opErr.Err.(type) {
-			case *os.SyscallError:
-				if errno, ok := t.Err.(syscall.Errno); ok {
-					switch errno {
-					case syscall.ECONNABORTED:
-						m.isConnected = false
-						m.iLogU.GetLogger().Warn("modbus connection aborted,reconnecting")
-					default:
-						m.iLogU.GetLogger().Warn("unexpected syscall error occurred while reading modbus", zap.String("portName", portInfo.PortName),
-							zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-					}
-				}
-			default:
-				m.iLogU.GetLogger().Warn("unknown operation error", zap.String("error type", reflect.TypeOf(opErr.Err).String()), zap.Error(err))
-			}
-		} else {
-			m.iLogU.GetLogger().Warn("error occurred while reading modbus", zap.String("portName", portInfo.PortName),
-				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-		}
+		return m.checkError(portInfo, deviceInfo, variableInfo.Name, err)
+	}
+	m.monitor.Succeeded()
+	return nil
+}
+
+// writeString the characters are padded with 0 to whole registers and written with function code 16
+func (m *modbusDriver) writeString(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
+	if variableInfo.Param.RegType != domain.RegTypeHoldingRegisterWithWriteMultiple {
+		return connection.InvalidAddress("string variable %s can only be written to holding registers with write multiple", variableInfo.Name)
+	}
+	result, err := m.dataTransform.ValueToByte(deviceInfo, variableInfo, inputValue)
+	if err != nil {
+		return connection.InvalidAddress("%v", err)
+	}
+	if len(result)%2 != 0 {
+		result = append(result, 0)
+	}
+	if len(result) == 0 {
+		return connection.InvalidAddress("invalid string length of %s", variableInfo.Name)
 	}
+	m.monitor.Begin()
+	if _, err = m.modbusClient.WriteMultipleRegisters(uint16(variableInfo.Param.RegAddr), uint16(len(result)/2), result); err != nil {
+		return m.checkError(portInfo, deviceInfo, variableInfo.Name, err)
+	}
+	m.monitor.Succeeded()
 	return nil
 }
 
+// Transmit sends a raw frame on the serial bus between the requests of the cycle sample
+func (m *modbusDriver) Transmit(request []byte, timeout time.Duration) ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.isConnected {
+		return nil, connection.ErrNotConnected
+	}
+	switch {
+	case m.asciiClientHandler != nil:
+		return m.asciiClientHandler.Send(request)
+	case m.rtuNetTransporter != nil:
+		return m.rtuNetTransporter.Send(request)
+	case m.rtuClientHandler != nil:
+		// the expected response length is calculated from the function code and the quantity
+		if len(request) < 6 {
+			return nil, fmt.Errorf("invalid length of rtu frame: %d", len(request))
+		}
+		return m.rtuClientHandler.Send(request)
+	}
+	return nil, fmt.Errorf("transmit is not supported by modbus tcp")
+}
+
+// Health the health of the connection of the port
+func (m *modbusDriver) Health() domain.PortHealth {
+	return m.monitor.Health()
+}
+
 func NewModbusUsecase(iLU domain.ILogUsecase) domain.IDataPointDriverUsecase {
 	m := &modbusDriver{iLogU: iLU}
 	return m
@@ -217,59 +252,161 @@ func NewModbusUsecase(iLU domain.ILogUsecase) domain.IDataPointDriverUsecase {
 func (m *modbusDriver) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
 	portName := portConfig.PortName
 	m.dataTransform = transform
+	m.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	m.monitor.SetMaxTimeouts(m.policy.MaxTimeouts)
 	if portConfig.PortType == domain.SerialType {
-		comNum := portConfig.Param.COM
-		deviceNode := usecase.ConvertComToDeviceNode(comNum)
-		rtuClientHandler := modbus.NewRTUClientHandler(deviceNode)
-		rtuClientHandler.Config = serial.Config{
-			Address:  deviceNode,
-			BaudRate: portConfig.Param.BandRate,
-			DataBits: portConfig.Param.DateBits,
-			StopBits: portConfig.Param.StopBit,
-			Parity:   string(portConfig.Param.Parity[0]),
-			Timeout:  time.Duration(portConfig.Param.RespTimeOutMs) * time.Millisecond,
+		serialConfig := connection.SerialConfig(portConfig.Param, connection.RespTimeout(portConfig.Param))
+		deviceNode := serialConfig.Address
+		if portConfig.DeviceType == domain.DeviceTypeModbusASCII {
+			asciiClientHandler := modbus.NewASCIIClientHandler(deviceNode)
+			asciiClientHandler.Config = serialConfig
+			m.asciiClientHandler = asciiClientHandler
+			m.modbusClient = modbus.NewClient(asciiClientHandler)
+			if err := asciiClientHandler.Connect(); err != nil {
+				m.iLogU.GetLogger().Error("modbus ascii connect failed", zap.String("port", portName), zap.String("deviceNode", deviceNode), zap.Error(err))
+				go m.reopenSerial(portName, deviceNode, err, asciiClientHandler.Connect)
+			} else {
+				m.isConnected = true
+				m.monitor.Connected()
+				m.iLogU.GetLogger().Info("modbus ascii connect succeeded", zap.String("port", portName), zap.String("deviceNode", deviceNode))
+			}
+			return
 		}
+		rtuClientHandler := modbus.NewRTUClientHandler(deviceNode)
+		rtuClientHandler.Config = serialConfig
+		m.rtuClientHandler = rtuClientHandler
+		m.modbusClient = modbus.NewClient(rtuClientHandler)
 		if err := rtuClientHandler.Connect(); err != nil {
 			m.iLogU.GetLogger().Error("modbus rtu connect failed", zap.String("port", portName), zap.String("deviceNode", deviceNode), zap.Error(err))
+			go m.reopenSerial(portName, deviceNode, err, rtuClientHandler.Connect)
 		} else {
 			m.isConnected = true
+			m.monitor.Connected()
 			m.iLogU.GetLogger().Info("modbus rtu connect succeeded", zap.String("port", portName), zap.String("deviceNode", deviceNode))
 		}
-		m.rtuClientHandler = rtuClientHandler
-		client := modbus.NewClient(rtuClientHandler)
-		m.modbusClient = client
 	} else {
+		// the network modes pack the frames as TCP or RTU, the port is never connected and reads fail
+		if portConfig.DeviceType == domain.DeviceTypeModbusASCII {
+			m.iLogU.GetLogger().Error("modbus ascii is not supported on network ports", zap.String("port", portName),
+				zap.String("netMode", netModeName(portConfig.Param.NetMode)))
+			return
+		}
+		if portConfig.DeviceType == domain.DeviceTypeModbusRTU && portConfig.Param.NetMode == domain.NetModeTcp {
+			m.iLogU.GetLogger().Warn("modbus rtu on a network port needs NetMode rtu over tcp or udp, the frames are sent as modbus tcp",
+				zap.String("port", portName))
+		}
 		deviceNode := fmt.Sprintf("%s:%d", portConfig.Param.IP, portConfig.Param.PortNumber)
-		tcpClientHandler := modbus.NewTCPClientHandler(deviceNode)
-		if err := tcpClientHandler.Connect(); err != nil {
-			m.iLogU.GetLogger().Error("modbus tcp connect failed", zap.String("port", portName), zap.String("deviceNode", deviceNode), zap.Error(err))
+		netMode := netModeName(portConfig.Param.NetMode)
+		if err := m.connectNet(portConfig, deviceNode); err != nil {
+			m.iLogU.GetLogger().Error("modbus tcp connect failed", zap.String("port", portName), zap.String("deviceNode", deviceNode),
+				zap.String("netMode", netMode), zap.Error(err))
 		} else {
-			m.isConnected = true
-			m.iLogU.GetLogger().Info("modbus tcp connect succeeded", zap.String("port", portName), zap.String("deviceNode", deviceNode))
+			m.iLogU.GetLogger().Info("modbus tcp connect succeeded", zap.String("port", portName), zap.String("deviceNode", deviceNode),
+				zap.String("netMode", netMode))
 		}
-		m.tcpClientHandler = tcpClientHandler
-		client := modbus.NewClient(tcpClientHandler)
-		m.modbusClient = client
 		go func() {
+			logger := m.iLogU.GetLogger()
+			backoff := connection.NewBackoff(m.policy)
 			for {
 				if m.isConnected {
 					time.Sleep(time.Second * 2)
 					continue
 				}
-				if m.tcpClientHandler != nil {
-					tcpClientHandler = modbus.NewTCPClientHandler(deviceNode)
-					if err := tcpClientHandler.Connect(); err != nil {
-						time.Sleep(time.Second * 2)
-						continue
-					} else {
-						m.isConnected = true
-						m.tcpClientHandler = tcpClientHandler
-						c := modbus.NewClient(tcpClientHandler)
-						m.modbusClient = c
-						m.iLogU.GetLogger().Info("modbus tcp reconnect success", zap.String("port", portName), zap.String("deviceNode", deviceNode))
-					}
+				if err := m.connectNet(portConfig, deviceNode); err != nil {
+					backoff.Wait(logger, "modbus tcp reconnect failed", err, zap.String("port", portName), zap.String("deviceNode", deviceNode),
+						zap.String("netMode", netMode))
+					continue
 				}
+				m.iLogU.GetLogger().Info("modbus tcp reconnect success", zap.String("port", portName), zap.String("deviceNode", deviceNode),
+					zap.String("netMode", netMode), zap.Int("attempts", backoff.Attempts()+1))
+				backoff.Reset()
 			}
 		}()
 	}
 }
+
+// serialHandler the handler of the serial port and its device node
+func (m *modbusDriver) serialHandler() (handler interface {
+	Connect() error
+	Close() error
+}, deviceNode string) {
+	if m.asciiClientHandler != nil {
+		return m.asciiClientHandler, m.asciiClientHandler.Address
+	}
+	return m.rtuClientHandler, m.rtuClientHandler.Address
+}
+
+// reopenSerial retries a serial port which could not be opened or timed out too often with the backoff of the port
+func (m *modbusDriver) reopenSerial(portName string, deviceNode string, err error, connect func() error) {
+	logger := m.iLogU.GetLogger()
+	backoff := connection.NewBackoff(m.policy)
+	for ; err != nil; err = connect() {
+		backoff.Wait(logger, "modbus serial port is not open", err, zap.String("port", portName), zap.String("deviceNode", deviceNode))
+	}
+	m.lock.Lock()
+	m.isConnected = true
+	m.monitor.Connected()
+	m.lock.Unlock()
+	logger.Info("modbus serial port reopened", zap.String("port", portName), zap.String("deviceNode", deviceNode),
+		zap.Int("attempts", backoff.Attempts()+1))
+}
+
+// connectNet replaces the connection of a network port, RTU over TCP/UDP packs the frames with the RTUClientHandler
+// and sends them through rtuNetTransporter
+func (m *modbusDriver) connectNet(portConfig *domain.DataPointPortConfig, deviceNode string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	switch portConfig.Param.NetMode {
+	case domain.NetModeRtuOverTcp, domain.NetModeRtuOverUdp:
+		network := "tcp"
+		if portConfig.Param.NetMode == domain.NetModeRtuOverUdp {
+			network = "udp"
+		}
+		transporter, err := newRtuNetTransporter(network, deviceNode, m.policy.DialTimeout, connection.RespTimeout(portConfig.Param))
+		if err != nil {
+			return err
+		}
+		m.closeNet()
+		m.rtuClientHandler = modbus.NewRTUClientHandler(deviceNode)
+		m.rtuNetTransporter = transporter
+		m.modbusClient = modbus.NewClient2(m.rtuClientHandler, transporter)
+	default:
+		tcpClientHandler := modbus.NewTCPClientHandler(deviceNode)
+		// the handler dials with its response timeout
+		responseTimeout := tcpClientHandler.Timeout
+		tcpClientHandler.Timeout = m.policy.DialTimeout
+		err := tcpClientHandler.Connect()
+		tcpClientHandler.Timeout = responseTimeout
+		if err != nil {
+			return err
+		}
+		m.closeNet()
+		m.tcpClientHandler = tcpClientHandler
+		m.modbusClient = modbus.NewClient(tcpClientHandler)
+	}
+	m.isConnected = true
+	m.monitor.Connected()
+	return nil
+}
+
+// closeNet releases the connection replaced by a reconnect
+func (m *modbusDriver) closeNet() {
+	if m.tcpClientHandler != nil {
+		_ = m.tcpClientHandler.Close()
+	}
+	if m.rtuNetTransporter != nil {
+		_ = m.rtuNetTransporter.Close()
+	}
+}
+
+// setSlaveId the slave id belongs to the packager of the transport in use
+func (m *modbusDriver) setSlaveId(portInfo *domain.DataPointPortConfig, slaveId uint8) {
+	switch {
+	case portInfo.PortType == domain.NetType && portInfo.Param.NetMode == domain.NetModeTcp:
+		m.tcpClientHandler.SlaveId = slaveId
+	case m.asciiClientHandler != nil:
+		m.asciiClientHandler.SlaveId = slaveId
+	default:
+		m.rtuClientHandler.SlaveId = slaveId
+	}
+}
@@ -0,0 +1,70 @@
+package modbus
+
+import (
+	"bytes"
+	"didaGatewayCenter/dataTransform"
+	"didaGatewayCenter/domain"
+	"github.com/HarryChen001/go-modbus"
+	"testing"
+)
+
+// recordingClient keeps the registers written by the driver
+type recordingClient struct {
+	modbus.Client
+	address uint16
+	value   []byte
+}
+
+func (c *recordingClient) WriteSingleRegister(address, value uint16) ([]byte, error) {
+	c.address, c.value = address, []byte{byte(value >> 8), byte(value)}
+	return nil, nil
+}
+
+func (c *recordingClient) WriteMultipleRegisters(address, quantity uint16, value []byte) ([]byte, error) {
+	c.address, c.value = address, value
+	return nil, nil
+}
+
+func TestWriteScalesOnce(t *testing.T) {
+	port := &domain.DataPointPortConfig{PortType: domain.NetType}
+	port.Param.NetMode = domain.NetModeTcp
+	device := &domain.DeviceList{LongOrder: domain.ByteOrderABCD, LongLongOrder: domain.ByteOrderABCD}
+	tests := []struct {
+		name     string
+		regType  domain.RegisterType
+		dataType domain.DataType
+		offset   float64
+		modulus  float64
+		input    interface{}
+		want     []byte
+		wantErr  bool
+	}{
+		{"single register", domain.RegTypeHoldingRegisterWithWriteSingle, domain.VarDataTypeUint16, 0, 0.1, float64(12), []byte{0x00, 0x78}, false},
+		{"single register with offset", domain.RegTypeHoldingRegisterWithWriteSingle, domain.VarDataTypeInt16, 10, 0.5, float64(5), []byte{0xFF, 0xF6}, false},
+		{"multiple registers", domain.RegTypeHoldingRegisterWithWriteMultiple, domain.VarDataTypeInt32, 0, 0.01, float64(1.5), []byte{0x00, 0x00, 0x00, 0x96}, false},
+		{"wide value in a single register", domain.RegTypeHoldingRegisterWithWriteSingle, domain.VarDataTypeUint32, 0, 1, float64(1), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingClient{}
+			m := &modbusDriver{
+				isConnected:      true,
+				tcpClientHandler: &modbus.TCPClientHandler{},
+				modbusClient:     client,
+				dataTransform:    dataTransform.NewDataTransformUsecase(),
+			}
+			v := variable(tt.regType, 7, tt.dataType)
+			v.Offset, v.Modulus = tt.offset, tt.modulus
+			err := m.Write(port, device, v, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if client.address != 7 || !bytes.Equal(client.value, tt.want) {
+				t.Fatalf("wrote % X to %d, want % X to 7", client.value, client.address, tt.want)
+			}
+		})
+	}
+}
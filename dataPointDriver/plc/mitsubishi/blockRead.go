@@ -0,0 +1,216 @@
+package mitsubishi
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataPointDriver/plc/mitsubishi/protocolStack"
+	"didaGatewayCenter/domain"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// batch variables of a random read or a multi-block batch read, indexes are of the variable list
+type batch struct {
+	multiBlock bool
+	indexes    []int
+}
+
+// ReadBlock reads the variables of a device with multi-block batch reads for the adjacent variables and random reads
+// for the rest, the protocols without batch reads and the variables rejected by the plc are read separately
+func (s *mitsubishi) ReadBlock(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList []*domain.DataPointVariableList) ([]domain.IValueType, []error) {
+	values := make([]domain.IValueType, len(variableList))
+	errs := make([]error, len(variableList))
+	if !s.isConnected {
+		time.Sleep(time.Second)
+		for index := range errs {
+			errs[index] = connection.ErrNotConnected
+		}
+		return values, errs
+	}
+	batchReader, ok := s.q.(protocolStack.BatchReader)
+	if !ok || portInfo.DeviceType == domain.DeviceTypeMitsubishiProgramPort {
+		for index, variable := range variableList {
+			values[index], errs[index] = s.Read(portInfo, deviceInfo, variable)
+		}
+		return values, errs
+	}
+	items := make([]protocolStack.BatchItem, len(variableList))
+	var planned, separate []int
+	for index, variable := range variableList {
+		length, isBit := getLength(variable)
+		code := getCode(portInfo, variable)
+		// random reads take words and double words only, strings are read separately
+		if code == nil || length == 0 || variable.DataType == domain.VarDataTypeString {
+			separate = append(separate, index)
+			continue
+		}
+		items[index] = protocolStack.BatchItem{Code: code, Address: variable.Param.RegAddr, Length: length, IsBit: isBit}
+		planned = append(planned, index)
+	}
+	for _, b := range planBatches(items, planned) {
+		if !s.isConnected {
+			setError(errs, b.indexes, connection.ErrNotConnected)
+			continue
+		}
+		if !s.readBatch(batchReader, portInfo, deviceInfo, variableList, items, b, values, errs) {
+			separate = append(separate, b.indexes...)
+		}
+	}
+	for _, index := range separate {
+		if !s.isConnected {
+			errs[index] = connection.ErrNotConnected
+			continue
+		}
+		values[index], errs[index] = s.Read(portInfo, deviceInfo, variableList[index])
+	}
+	return values, errs
+}
+
+func setError(errs []error, indexes []int, err error) {
+	for _, index := range indexes {
+		errs[index] = err
+	}
+}
+
+// readBatch false when the plc rejected the request and the variables should be read separately
+func (s *mitsubishi) readBatch(batchReader protocolStack.BatchReader, portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList,
+	variableList []*domain.DataPointVariableList, items []protocolStack.BatchItem, b batch, values []domain.IValueType, errs []error) bool {
+	batchItems := make([]protocolStack.BatchItem, len(b.indexes))
+	for i, index := range b.indexes {
+		batchItems[i] = items[index]
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.setStation(deviceInfo)
+	var frame []byte
+	if b.multiBlock {
+		frame = batchReader.MultiBlockRead(batchItems)
+	} else {
+		frame = batchReader.RandomRead(batchItems)
+	}
+	s.monitor.Begin()
+	r, err := s.conn.WriteReadTimeout(frame, connection.RespTimeout(portInfo.Param))
+	if err != nil {
+		setError(errs, b.indexes, s.checkError(portInfo, deviceInfo, variableList[b.indexes[0]].Name, err))
+		return true
+	}
+	results, err := batchReader.ParseBatch(r)
+	if err != nil {
+		var endCodeErr protocolStack.EndCodeError
+		if errors.As(err, &endCodeErr) {
+			s.iLogU.GetLogger().Warn("batch read rejected, reading the variables separately", zap.String("portName", portInfo.PortName),
+				zap.String("deviceName", deviceInfo.DevName), zap.Int("variableCount", len(b.indexes)), zap.Error(err))
+			return false
+		}
+		s.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList[b.indexes[0]].Name),
+			zap.Int("variableCount", len(b.indexes)), zap.Error(err))
+		setError(errs, b.indexes, connection.InvalidResponse(err))
+		return true
+	}
+	s.monitor.Succeeded()
+	for i, index := range b.indexes {
+		var valueByte []byte
+		if i < len(results) {
+			valueByte = results[i]
+		}
+		if valueByte == nil {
+			errs[index] = connection.InvalidResponse(fmt.Errorf("no data in the batch read response"))
+			continue
+		}
+		variable := variableList[index]
+		if len(valueByte) == 1 {
+			valueByte = append([]byte{0}, valueByte[0])
+		}
+		value, err := s.iDTU.ByteToValue(deviceInfo, variable, valueByte)
+		if err != nil {
+			s.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variable.Name), zap.Error(err))
+			errs[index] = connection.InvalidResponse(err)
+			continue
+		}
+		values[index] = value
+	}
+	return true
+}
+
+// planBatches the items with an adjacent item of the same device go to multi-block batch reads, the isolated items
+// go to random reads, both are split by the limits of a request
+func planBatches(items []protocolStack.BatchItem, indexes []int) []batch {
+	// the devices keep the order they first appear in
+	codeOrder := make(map[*protocolStack.RegCode]int)
+	for _, index := range indexes {
+		if _, ok := codeOrder[items[index].Code]; !ok {
+			codeOrder[items[index].Code] = len(codeOrder)
+		}
+	}
+	sorted := append([]int(nil), indexes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := items[sorted[i]], items[sorted[j]]
+		if a.Code != b.Code {
+			return codeOrder[a.Code] < codeOrder[b.Code]
+		}
+		if a.IsBit != b.IsBit {
+			return !a.IsBit
+		}
+		return a.Address < b.Address
+	})
+	adjacent := func(a, b protocolStack.BatchItem) bool {
+		if a.Code != b.Code || a.IsBit != b.IsBit {
+			return false
+		}
+		if a.IsBit {
+			return b.Address-a.Address <= 1
+		}
+		return b.Address <= a.Address+a.Length
+	}
+	var blockItems, randomItems []int
+	for i, index := range sorted {
+		if (i > 0 && adjacent(items[sorted[i-1]], items[index])) ||
+			(i < len(sorted)-1 && adjacent(items[index], items[sorted[i+1]])) {
+			blockItems = append(blockItems, index)
+		} else {
+			randomItems = append(randomItems, index)
+		}
+	}
+
+	var batches []batch
+	// the blocks of a request are at most as many as the items, the bit items take at most a word each
+	current := batch{multiBlock: true}
+	words := 0
+	for _, index := range blockItems {
+		itemWords := items[index].Length
+		if items[index].IsBit {
+			itemWords = 1
+		}
+		if len(current.indexes) > 0 && (len(current.indexes) == protocolStack.MaxMultiBlockReadBlocks ||
+			words+itemWords > protocolStack.MaxMultiBlockReadPoints) {
+			batches = append(batches, current)
+			current = batch{multiBlock: true}
+			words = 0
+		}
+		current.indexes = append(current.indexes, index)
+		words += itemWords
+	}
+	if len(current.indexes) > 0 {
+		batches = append(batches, current)
+	}
+	current = batch{}
+	points := 0
+	for _, index := range randomItems {
+		itemPoints := items[index].Points()
+		if len(current.indexes) > 0 && points+itemPoints > protocolStack.MaxRandomReadPoints {
+			batches = append(batches, current)
+			current = batch{}
+			points = 0
+		}
+		current.indexes = append(current.indexes, index)
+		points += itemPoints
+	}
+	if len(current.indexes) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
@@ -0,0 +1,158 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	enq = 0x05
+	etx = 0x03
+	ack = 0x06
+	nak = 0x15
+)
+
+var crlf = []byte{'\r', '\n'}
+
+// computerLinkDevice device name of the A compatible 1C frame, 5 characters including the device number,
+// two-letter devices keep 3 digits
+func computerLinkDevice(code *RegCode, startAddress int) (string, error) {
+	if code == nil {
+		return "", fmt.Errorf("device is not set")
+	}
+	switch code {
+	case &StepRelay, &SpecialRelay: // the S register of A series and FX is the step relay
+		return fmt.Sprintf("S%.4d", startAddress), nil
+	case &SpecialRegister:
+		return fmt.Sprintf("D%.4d", startAddress), nil
+	case &DirectInput, &DirectOutput, &VariableAddressRegister, &SumTimerContact, &SumTimerCoil, &SumTimerCurrent,
+		&LinkSpecialRelay, &LinkSpecialRegister:
+		return "", fmt.Errorf("device %s is not supported by computer link", code.asciiCode)
+	}
+	name := strings.TrimSuffix(string(code.asciiCode), "*")
+	if len(name) == 1 {
+		return fmt.Sprintf("%s%.4d", name, startAddress), nil
+	}
+	return fmt.Sprintf("%s%.3d", name, startAddress), nil
+}
+
+func (c *ComputerLink) SetStation(station int) {
+	c.station = station
+}
+
+func (c *ComputerLink) ReadVar(isBit bool, code *RegCode, startAddress int, length int) []byte {
+	device, err := computerLinkDevice(code, startAddress)
+	if err != nil {
+		return nil
+	}
+	command := "WR"
+	if isBit {
+		command = "BR"
+	}
+	c.isBit = isBit
+	c.responseCount = length
+	return c.frame(command, fmt.Sprintf("%s%.2X", device, length))
+}
+
+// WriteVar words are sent as 4 hexadecimal characters each, the lowest word first, bits as a single character
+func (c *ComputerLink) WriteVar(isBit bool, code *RegCode, startAddress int, length int, data []byte) []byte {
+	device, err := computerLinkDevice(code, startAddress)
+	if err != nil {
+		return nil
+	}
+	c.isBit = isBit
+	c.responseCount = 0
+	if isBit {
+		value := "0"
+		if data[len(data)-1] != 0 {
+			value = "1"
+		}
+		return c.frame("BW", fmt.Sprintf("%s%.2X%s", device, length, value))
+	}
+	words := ""
+	for i := len(data) - 2; i >= 0; i -= 2 {
+		words += fmt.Sprintf("%X", data[i:i+2])
+	}
+	return c.frame("WW", fmt.Sprintf("%s%.2X%s", device, length, words))
+}
+
+// Parse read responses are STX + station + pc number + data + ETX + sum check, write responses ACK + station +
+// pc number and errors NAK + station + pc number + error code
+func (c *ComputerLink) Parse(input []byte) ([]byte, error) {
+	input = bytes.TrimSuffix(input, crlf)
+	start := bytes.IndexAny(input, string([]byte{stx, ack, nak}))
+	if start < 0 {
+		return nil, fmt.Errorf("no frame start found: % X", input)
+	}
+	input = input[start:]
+	if len(input) < 5 {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if station := fmt.Sprintf("%.2X%.2X", c.station, c.pcNum); string(input[1:5]) != station {
+		return nil, fmt.Errorf("unexpected station and pc number: %s", input[1:5])
+	}
+	switch input[0] {
+	case ack:
+		return nil, nil
+	case nak:
+		if len(input) < 7 {
+			return nil, fmt.Errorf("negative acknowledge without error code")
+		}
+		return nil, fmt.Errorf("invalid end code: %s", input[5:7])
+	}
+	end := bytes.IndexByte(input, etx)
+	if end < 0 || len(input) < end+3 {
+		return nil, fmt.Errorf("incomplete data: % X", input)
+	}
+	if sum := fmt.Sprintf("%.2X", computerLinkSum(input[1:end+1])); sum != string(input[end+1:end+3]) {
+		return nil, fmt.Errorf("sum check is %s not match %s which received", sum, input[end+1:end+3])
+	}
+	data := input[5:end]
+	if c.isBit {
+		if len(data) < c.responseCount {
+			return nil, fmt.Errorf("incomplete data,require %d but got %d", c.responseCount, len(data))
+		}
+		result := make([]byte, c.responseCount)
+		for index := range result {
+			if data[index] != '0' {
+				result[index] = 1
+			}
+		}
+		return result, nil
+	}
+	if len(data) < c.responseCount*4 {
+		return nil, fmt.Errorf("incomplete data,require %d but got %d", c.responseCount*4, len(data))
+	}
+	words, err := hex.DecodeString(string(data[:c.responseCount*4]))
+	if err != nil {
+		return nil, err
+	}
+	// the highest word first like the binary frames
+	result := make([]byte, 0, len(words))
+	for i := len(words) - 2; i >= 0; i -= 2 {
+		result = append(result, words[i:i+2]...)
+	}
+	return result, nil
+}
+
+// frame ENQ + station(2) + pc number(2) + command(2) + message wait(1) + text + sum check(2),
+// the sum check covers station to the end of the text, format 4 appends CR LF
+func (c *ComputerLink) frame(command string, text string) []byte {
+	body := fmt.Sprintf("%.2X%.2X%s%X%s", c.station, c.pcNum, command, c.messageWait, text)
+	result := append([]byte{enq}, body...)
+	result = append(result, fmt.Sprintf("%.2X", computerLinkSum([]byte(body)))...)
+	if c.format == ComputerLinkFormat4 {
+		result = append(result, crlf...)
+	}
+	return result
+}
+
+func computerLinkSum(input []byte) byte {
+	sum := byte(0)
+	for _, temp := range input {
+		sum += temp
+	}
+	return sum
+}
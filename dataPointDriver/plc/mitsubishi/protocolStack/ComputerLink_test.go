@@ -0,0 +1,81 @@
+package protocolStack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestComputerLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   ComputerLinkFormat
+		station  int
+		isBit    bool
+		code     *RegCode
+		address  int
+		length   int
+		request  string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "read D0", format: ComputerLinkFormat1, code: &DataRegister, length: 1,
+			request: "\x0500FFWR0D0000012A", response: "\x0200FF1234\x03B9", want: "1234",
+		},
+		{
+			name: "read X0 2 bits", format: ComputerLinkFormat1, isBit: true, code: &Input, length: 2,
+			request: "\x0500FFBR0X0000022A", response: "\x0200FF10\x0350", want: "0100",
+		},
+		{
+			name: "format 4", format: ComputerLinkFormat4, code: &DataRegister, length: 1,
+			request: "\x0500FFWR0D0000012A\r\n", response: "\x0200FF1234\x03B9\r\n", want: "1234",
+		},
+		{
+			name: "station 5 reads 3 words from D100", format: ComputerLinkFormat1, station: 5, code: &DataRegister, address: 100, length: 3,
+			request: "\x0505FFWR0D01000332", response: "\x0205FF000100020003\x033A", want: "000300020001",
+		},
+		{
+			name: "sum check", format: ComputerLinkFormat1, code: &DataRegister, length: 1,
+			request: "\x0500FFWR0D0000012A", response: "\x0200FF1234\x03B8", wantErr: true,
+		},
+		{
+			name: "other station", format: ComputerLinkFormat1, code: &DataRegister, length: 1,
+			request: "\x0500FFWR0D0000012A", response: "\x0201FF1234\x03BA", wantErr: true,
+		},
+		{
+			name: "negative acknowledge", format: ComputerLinkFormat1, code: &DataRegister, length: 1,
+			request: "\x0500FFWR0D0000012A", response: "\x1500FF06", wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewComputerLink(tt.format)
+			c.SetStation(tt.station)
+			if got := string(c.ReadVar(tt.isBit, tt.code, tt.address, tt.length)); got != tt.request {
+				t.Fatalf("ReadVar() = %q, want %q", got, tt.request)
+			}
+			got, err := c.Parse([]byte(tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Parse() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+
+	c := NewComputerLink(ComputerLinkFormat1)
+	if got, want := string(c.WriteVar(false, &DataRegister, 0, 2, []byte{0x56, 0x78, 0x12, 0x34})), "\x0500FFWW0D00000212345678D4"; got != want {
+		t.Fatalf("WriteVar() = %q, want %q", got, want)
+	}
+	if got, err := c.Parse([]byte("\x0600FF")); got != nil || err != nil {
+		t.Fatalf("Parse() of the acknowledge = % X, %v", got, err)
+	}
+	if got := c.ReadVar(false, nil, 0, 1); got != nil {
+		t.Fatalf("ReadVar() without device = %q, want nil", got)
+	}
+	if got := c.WriteVar(false, nil, 0, 1, []byte{0x12, 0x34}); got != nil {
+		t.Fatalf("WriteVar() without device = %q, want nil", got)
+	}
+}
@@ -0,0 +1,149 @@
+package protocolStack
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	subHeader1EReadBit   byte = 0x00
+	subHeader1EReadWord  byte = 0x01
+	subHeader1EWriteBit  byte = 0x02
+	subHeader1EWriteWord byte = 0x03
+	subHeader1EResponse  byte = 0x80 // set in the subheader of every response
+
+	completeCode1EAbnormal byte = 0x5B // followed by the abnormal code
+)
+
+// a1eCode device codes of the 1E frame, the letter is in the high byte and sent last
+func a1eCode(code *RegCode) (uint16, error) {
+	if code == nil {
+		return 0, fmt.Errorf("device is not set")
+	}
+	switch code {
+	case &Input:
+		return 0x5820, nil
+	case &Output:
+		return 0x5920, nil
+	case &InternalRelay:
+		return 0x4D20, nil
+	case &StepRelay, &SpecialRelay: // the S register of A series and FX is the step relay
+		return 0x5320, nil
+	case &Alarm:
+		return 0x4620, nil
+	case &LinkRelay:
+		return 0x4220, nil
+	case &DataRegister, &SpecialRegister:
+		return 0x4420, nil
+	case &LinkRegister:
+		return 0x5720, nil
+	case &TimerContact:
+		return 0x5453, nil
+	case &TimerCoil:
+		return 0x5443, nil
+	case &TimerCurrent:
+		return 0x544E, nil
+	case &CounterContact:
+		return 0x4353, nil
+	case &CounterCoil:
+		return 0x4343, nil
+	case &CounterCurrent:
+		return 0x434E, nil
+	}
+	return 0, fmt.Errorf("device %s is not supported by 1E frame", code.asciiCode)
+}
+
+func (q *Qna1EBinaryProtocolStack) ReadVar(isBit bool, code *RegCode, startAddress int, length int) []byte {
+	subHeader := subHeader1EReadWord
+	if isBit {
+		subHeader = subHeader1EReadBit
+	}
+	result, err := q.header(subHeader, code, startAddress, length)
+	if err != nil {
+		return nil
+	}
+	q.isBit = isBit
+	q.responseCount = length
+	return result
+}
+
+// WriteVar bit devices are written one point per nibble, the highest nibble first
+func (q *Qna1EBinaryProtocolStack) WriteVar(isBit bool, code *RegCode, startAddress int, length int, data []byte) []byte {
+	subHeader := subHeader1EWriteWord
+	if isBit {
+		subHeader = subHeader1EWriteBit
+	}
+	result, err := q.header(subHeader, code, startAddress, length)
+	if err != nil {
+		return nil
+	}
+	q.isBit = isBit
+	q.responseCount = 0
+	if isBit {
+		if data[len(data)-1] != 0 {
+			return append(result, 0x10)
+		}
+		return append(result, 0x00)
+	}
+	writeData := make([]byte, len(data))
+	for index, singleD := range data {
+		writeData[len(data)-index-1] = singleD
+	}
+	return append(result, writeData...)
+}
+
+func (q *Qna1EBinaryProtocolStack) Parse(input []byte) ([]byte, error) {
+	if len(input) < 2 {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if input[0]&subHeader1EResponse == 0 {
+		return nil, fmt.Errorf("invalid subheader: %X", input[0])
+	}
+	if completeCode := input[1]; completeCode != 0 {
+		if completeCode == completeCode1EAbnormal && len(input) > 2 {
+			return nil, fmt.Errorf("invalid end code: %X, abnormal code: %X", completeCode, input[2])
+		}
+		return nil, fmt.Errorf("invalid end code: %X", completeCode)
+	}
+	if q.responseCount == 0 {
+		return nil, nil
+	}
+	data := input[2:]
+	if q.isBit {
+		if len(data) < (q.responseCount+1)/2 {
+			return nil, fmt.Errorf("incomplete data,require %d but got %d", (q.responseCount+1)/2, len(data))
+		}
+		result := make([]byte, q.responseCount)
+		for index := range result {
+			result[index] = data[index/2] >> (4 * (1 - index%2)) & 0x01
+		}
+		return result, nil
+	}
+	if len(data) < q.responseCount*2 {
+		return nil, fmt.Errorf("incomplete data,require %d but got %d", q.responseCount*2, len(data))
+	}
+	data = data[:q.responseCount*2]
+	result := make([]byte, len(data))
+	for index, singleR := range data {
+		result[len(data)-1-index] = singleR
+	}
+	return result, nil
+}
+
+// header subheader(1) + pc number(1) + monitoring timer(2) + head device(4) + device code(2) + points(1) + 0x00,
+// 256 points are sent as 0
+func (q *Qna1EBinaryProtocolStack) header(subHeader byte, code *RegCode, startAddress int, length int) ([]byte, error) {
+	deviceCode, err := a1eCode(code)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 12)
+	result[0] = subHeader
+	result[1] = q.pcNum
+	binary.LittleEndian.PutUint16(result[2:4], q.monitorTimer)
+	binary.LittleEndian.PutUint32(result[4:8], uint32(startAddress))
+	binary.LittleEndian.PutUint16(result[8:10], deviceCode)
+	result[10] = byte(length)
+	result[11] = 0x00
+	return result, nil
+}
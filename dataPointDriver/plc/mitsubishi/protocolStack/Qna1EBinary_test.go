@@ -0,0 +1,86 @@
+package protocolStack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestQna1eBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		isBit    bool
+		code     *RegCode
+		address  int
+		length   int
+		request  string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "read D100 3 words", code: &DataRegister, address: 100, length: 3,
+			request:  "01 FF 0A00 64000000 2044 03 00",
+			response: "81 00 3412 7856 BC9A",
+			want:     "9ABC 5678 1234",
+		},
+		{
+			name: "read M0 4 bits", isBit: true, code: &InternalRelay, length: 4,
+			request:  "00 FF 0A00 00000000 204D 04 00",
+			response: "80 00 10 01",
+			want:     "01 00 00 01",
+		},
+		{
+			name: "read T10 current value", code: &TimerCurrent, address: 10, length: 1,
+			request:  "01 FF 0A00 0A000000 4E54 01 00",
+			response: "81 00 6400",
+			want:     "0064",
+		},
+		{
+			name: "abnormal", code: &DataRegister, address: 100, length: 1,
+			request:  "01 FF 0A00 64000000 2044 01 00",
+			response: "81 5B 10",
+			wantErr:  true,
+		},
+		{
+			name: "incomplete", code: &DataRegister, address: 100, length: 2,
+			request:  "01 FF 0A00 64000000 2044 02 00",
+			response: "81 00 3412",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQna1eBinary()
+			if got, want := q.ReadVar(tt.isBit, tt.code, tt.address, tt.length), decodeHex(t, tt.request); !bytes.Equal(got, want) {
+				t.Fatalf("ReadVar() = % X, want % X", got, want)
+			}
+			got, err := q.Parse(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Parse() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+
+	q := NewQna1eBinary()
+	if got, want := q.WriteVar(false, &DataRegister, 0, 1, []byte{0x12, 0x34}), decodeHex(t, "03 FF 0A00 00000000 2044 01 00 3412"); !bytes.Equal(got, want) {
+		t.Fatalf("WriteVar() of a word = % X, want % X", got, want)
+	}
+	if got, want := q.WriteVar(true, &Output, 5, 1, []byte{0x00, 0x01}), decodeHex(t, "02 FF 0A00 05000000 2059 01 00 10"); !bytes.Equal(got, want) {
+		t.Fatalf("WriteVar() of a bit = % X, want % X", got, want)
+	}
+	if got, err := q.Parse(decodeHex(t, "82 00")); got != nil || err != nil {
+		t.Fatalf("Parse() of the write response = % X, %v", got, err)
+	}
+	if got := q.ReadVar(false, &DirectInput, 0, 1); got != nil {
+		t.Fatalf("ReadVar() of a device without 1E code = % X, want nil", got)
+	}
+	if got := q.ReadVar(false, nil, 0, 1); got != nil {
+		t.Fatalf("ReadVar() without device = % X, want nil", got)
+	}
+	if got := q.WriteVar(false, nil, 0, 1, []byte{0x12, 0x34}); got != nil {
+		t.Fatalf("WriteVar() without device = % X, want nil", got)
+	}
+}
@@ -102,3 +102,66 @@ func (q *Qna3EAsciiProtocolStack) WriteVar(isBit bool, code *RegCode, startAddre
 		dataLength, cpuTimer, commandASCII, childCommandASCII, uintCode, startUint, uintLength, writeDataByte)
 	return []byte(result)
 }
+
+func (q *Qna3EAsciiProtocolStack) RandomRead(items []BatchItem) []byte {
+	words, dwords := q.planRandomRead(items)
+	result := q.batchHeader(readRandom, 16+(len(words)+len(dwords))*8)
+	result += fmt.Sprintf("%.2X%.2X", len(words), len(dwords))
+	for _, singleDevice := range append(words, dwords...) {
+		result += asciiDevice(singleDevice)
+	}
+	return []byte(result)
+}
+
+func (q *Qna3EAsciiProtocolStack) MultiBlockRead(items []BatchItem) []byte {
+	wordBlocks, bitBlocks := q.planMultiBlockRead(items)
+	result := q.batchHeader(readMultiBlock, 16+(len(wordBlocks)+len(bitBlocks))*12)
+	result += fmt.Sprintf("%.2X%.2X", len(wordBlocks), len(bitBlocks))
+	for _, singleBlock := range append(wordBlocks, bitBlocks...) {
+		result += fmt.Sprintf("%s%.4X", asciiDevice(singleBlock), singleBlock.points())
+	}
+	return []byte(result)
+}
+
+// ParseBatch every word is 4 hexadecimal characters, the data of a word item is in address order like Parse
+func (q *Qna3EAsciiProtocolStack) ParseBatch(input []byte) ([][]byte, error) {
+	if len(input) < 22 {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	endCodeByte, err := hex.DecodeString(string(input[18:22]))
+	if err != nil {
+		return nil, err
+	}
+	if endCode := binary.BigEndian.Uint16(endCodeByte); endCode != 0 {
+		return nil, EndCodeError(endCode)
+	}
+	data, err := hex.DecodeString(string(input[22 : 22+(len(input)-22)/4*4]))
+	if err != nil {
+		return nil, err
+	}
+	words := make([]uint16, len(data)/2)
+	for index := range words {
+		words[index] = binary.BigEndian.Uint16(data[index*2:])
+	}
+	for index := q.batch.dwordStart; index+1 < len(words); index += 2 {
+		words[index], words[index+1] = words[index+1], words[index]
+	}
+	return q.items(words, func(words []uint16) []byte {
+		result := make([]byte, 0, len(words)*2)
+		for _, singleWord := range words {
+			result = append(result, byte(singleWord>>8), byte(singleWord))
+		}
+		return result
+	})
+}
+
+// batchHeader subheader to the sub command of the word units
+func (q *Qna3EAsciiProtocolStack) batchHeader(command commandType, dataLength int) string {
+	return fmt.Sprintf("%.4X%.2X%.2X%.4X%.2X%.4X%.4X%.4X%.4X", q.deputyHeader, q.networkNum, q.plcNum, q.targetIONum,
+		q.targetModuleStation, dataLength, q.cpuTimer, command, childCommandWord)
+}
+
+// asciiDevice device code in 2 characters followed by the device number in 6 digits
+func asciiDevice(singleDevice batchDevice) string {
+	return fmt.Sprintf("%s%.6d", singleDevice.code.asciiCode, singleDevice.address)
+}
@@ -116,3 +116,70 @@ func (q *Qna3EBinaryProtocolStack) WriteVar(isBit bool, code *RegCode, startAddr
 
 	return result
 }
+
+func (q *Qna3EBinaryProtocolStack) RandomRead(items []BatchItem) []byte {
+	words, dwords := q.planRandomRead(items)
+	result := q.batchHeader(readRandom, 8+(len(words)+len(dwords))*4)
+	result = append(result, byte(len(words)), byte(len(dwords)))
+	for _, singleDevice := range append(words, dwords...) {
+		result = append(result, binaryDevice(singleDevice)...)
+	}
+	return result
+}
+
+func (q *Qna3EBinaryProtocolStack) MultiBlockRead(items []BatchItem) []byte {
+	wordBlocks, bitBlocks := q.planMultiBlockRead(items)
+	result := q.batchHeader(readMultiBlock, 8+(len(wordBlocks)+len(bitBlocks))*6)
+	result = append(result, byte(len(wordBlocks)), byte(len(bitBlocks)))
+	for _, singleBlock := range append(wordBlocks, bitBlocks...) {
+		points := make([]byte, 2)
+		binary.LittleEndian.PutUint16(points, uint16(singleBlock.points()))
+		result = append(result, binaryDevice(singleBlock)...)
+		result = append(result, points...)
+	}
+	return result
+}
+
+// ParseBatch the words of the response are little endian, the data of a word item is reversed like Parse
+func (q *Qna3EBinaryProtocolStack) ParseBatch(input []byte) ([][]byte, error) {
+	if len(input) < 11 {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if endCode := binary.LittleEndian.Uint16(input[9:11]); endCode != 0 {
+		return nil, EndCodeError(endCode)
+	}
+	data := input[11:]
+	words := make([]uint16, len(data)/2)
+	for index := range words {
+		words[index] = binary.LittleEndian.Uint16(data[index*2:])
+	}
+	return q.items(words, func(words []uint16) []byte {
+		result := make([]byte, 0, len(words)*2)
+		for index := len(words) - 1; index >= 0; index-- {
+			result = append(result, byte(words[index]>>8), byte(words[index]))
+		}
+		return result
+	})
+}
+
+// batchHeader subheader to the sub command of the word units
+func (q *Qna3EBinaryProtocolStack) batchHeader(command commandType, dataLength int) []byte {
+	result := make([]byte, 15)
+	binary.BigEndian.PutUint16(result[0:2], q.deputyHeader)
+	result[2], result[3] = q.networkNum, q.plcNum
+	binary.LittleEndian.PutUint16(result[4:6], q.targetIONum)
+	result[6] = q.targetModuleStation
+	binary.LittleEndian.PutUint16(result[7:9], uint16(dataLength))
+	binary.LittleEndian.PutUint16(result[9:11], q.cpuTimer)
+	binary.LittleEndian.PutUint16(result[11:13], uint16(command))
+	binary.LittleEndian.PutUint16(result[13:15], uint16(childCommandWord))
+	return result
+}
+
+// binaryDevice device number in 3 bytes followed by the device code
+func binaryDevice(singleDevice batchDevice) []byte {
+	result := make([]byte, 4)
+	binary.LittleEndian.PutUint32(result, uint32(singleDevice.address))
+	result[3] = byte(singleDevice.code.binaryCode)
+	return result
+}
@@ -0,0 +1,180 @@
+package protocolStack
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// MaxRandomReadPoints word and double word points of a random read
+	MaxRandomReadPoints = 192
+	// MaxMultiBlockReadBlocks blocks of a multi-block batch read
+	MaxMultiBlockReadBlocks = 120
+	// MaxMultiBlockReadPoints words of all blocks of a multi-block batch read
+	MaxMultiBlockReadPoints = 960
+)
+
+// BatchItem a variable of random and multi-block batch reads, Length is in words, bit items are a single bit of
+// a bit device and are read 16 bits a word
+type BatchItem struct {
+	Code    *RegCode
+	Address int
+	Length  int
+	IsBit   bool
+}
+
+// Points words and double words the item takes in a random read
+func (b BatchItem) Points() int {
+	if b.IsBit || b.Length < 2 {
+		return 1
+	}
+	return b.Length / 2
+}
+
+// EndCodeError the plc rejected the request
+type EndCodeError uint16
+
+func (e EndCodeError) Error() string {
+	return fmt.Sprintf("invalid end code: %d", uint16(e))
+}
+
+// batchDevice a point of a random read or a block of a multi-block read, length is in bits for bit blocks
+type batchDevice struct {
+	code    *RegCode
+	address int
+	length  int
+	isBit   bool
+}
+
+func (b *batchDevice) points() int {
+	if b.isBit {
+		return (b.length + 15) / 16
+	}
+	return b.length
+}
+
+// batchLayout where the items are in the words of the response
+type batchLayout struct {
+	words [][]int // word indexes of every item in address order
+	bits  []int   // bit of the word of bit items, -1 for word items
+	count int     // words of the response
+	// the double words of a random read start here, ASCII frames send them as 8 characters with the high word first
+	dwordStart int
+}
+
+// planRandomRead the words come first in the response, then the double words with the low word first
+func (q *QnaProtocolStack) planRandomRead(items []BatchItem) (words []batchDevice, dwords []batchDevice) {
+	q.batch = batchLayout{words: make([][]int, len(items)), bits: make([]int, len(items))}
+	var wordRefs, dwordRefs []int
+	for index, singleItem := range items {
+		q.batch.bits[index] = -1
+		if singleItem.IsBit || singleItem.Length == 1 {
+			if singleItem.IsBit {
+				q.batch.bits[index] = 0
+			}
+			words = append(words, batchDevice{code: singleItem.Code, address: singleItem.Address, length: 1})
+			wordRefs = append(wordRefs, index)
+			continue
+		}
+		for i := 0; i < singleItem.Length/2; i++ {
+			dwords = append(dwords, batchDevice{code: singleItem.Code, address: singleItem.Address + i*2, length: 2})
+			dwordRefs = append(dwordRefs, index)
+		}
+	}
+	for i, index := range wordRefs {
+		q.batch.words[index] = append(q.batch.words[index], i)
+	}
+	for i, index := range dwordRefs {
+		base := len(words) + i*2
+		q.batch.words[index] = append(q.batch.words[index], base, base+1)
+	}
+	q.batch.count = len(words) + len(dwords)*2
+	q.batch.dwordStart = len(words)
+	return words, dwords
+}
+
+// planMultiBlockRead the word blocks come first in the response, then the bit blocks
+func (q *QnaProtocolStack) planMultiBlockRead(items []BatchItem) (wordBlocks []batchDevice, bitBlocks []batchDevice) {
+	q.batch = batchLayout{words: make([][]int, len(items)), bits: make([]int, len(items))}
+	order := make([]int, len(items))
+	for index := range order {
+		order[index] = index
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := items[order[i]], items[order[j]]
+		if a.IsBit != b.IsBit {
+			return !a.IsBit
+		}
+		if a.Code.binaryCode != b.Code.binaryCode {
+			return a.Code.binaryCode < b.Code.binaryCode
+		}
+		return a.Address < b.Address
+	})
+	var blocks []batchDevice
+	blockOf := make([]int, len(items))
+	for _, index := range order {
+		singleItem := items[index]
+		length := singleItem.Length
+		if singleItem.IsBit {
+			length = 1
+		}
+		last := len(blocks) - 1
+		if last >= 0 && blocks[last].code == singleItem.Code && blocks[last].isBit == singleItem.IsBit &&
+			singleItem.Address <= blocks[last].address+blocks[last].length {
+			if end := singleItem.Address + length; end > blocks[last].address+blocks[last].length {
+				blocks[last].length = end - blocks[last].address
+			}
+		} else {
+			blocks = append(blocks, batchDevice{code: singleItem.Code, address: singleItem.Address, length: length, isBit: singleItem.IsBit})
+		}
+		blockOf[index] = len(blocks) - 1
+	}
+	base := make([]int, len(blocks))
+	for index := range blocks {
+		base[index] = q.batch.count
+		q.batch.count += blocks[index].points()
+		if blocks[index].isBit {
+			bitBlocks = append(bitBlocks, blocks[index])
+		} else {
+			wordBlocks = append(wordBlocks, blocks[index])
+		}
+	}
+	for index, singleItem := range items {
+		block := blocks[blockOf[index]]
+		offset := singleItem.Address - block.address
+		if singleItem.IsBit {
+			q.batch.words[index] = []int{base[blockOf[index]] + offset/16}
+			q.batch.bits[index] = offset % 16
+			continue
+		}
+		q.batch.bits[index] = -1
+		for i := 0; i < singleItem.Length; i++ {
+			q.batch.words[index] = append(q.batch.words[index], base[blockOf[index]]+offset+i)
+		}
+	}
+	q.batch.dwordStart = q.batch.count
+	return wordBlocks, bitBlocks
+}
+
+// items slices the words of the response into the items, form converts the words of a word item
+func (q *QnaProtocolStack) items(words []uint16, form func(words []uint16) []byte) ([][]byte, error) {
+	if len(words) < q.batch.count {
+		return nil, fmt.Errorf("incomplete data,require %d words but got %d", q.batch.count, len(words))
+	}
+	result := make([][]byte, len(q.batch.words))
+	for index, indexes := range q.batch.words {
+		if len(indexes) == 0 {
+			continue
+		}
+		if bit := q.batch.bits[index]; bit >= 0 {
+			result[index] = []byte{byte(words[indexes[0]] >> bit & 0x01)}
+			continue
+		}
+		itemWords := make([]uint16, len(indexes))
+		for i, wordIndex := range indexes {
+			itemWords[i] = words[wordIndex]
+		}
+		result[index] = form(itemWords)
+	}
+	return result, nil
+}
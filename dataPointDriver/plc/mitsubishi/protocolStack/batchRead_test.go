@@ -0,0 +1,180 @@
+package protocolStack
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// randomItems a word, a bit of a word device and a double word
+var randomItems = []BatchItem{
+	{Code: &DataRegister, Address: 100, Length: 1},
+	{Code: &InternalRelay, Address: 100, Length: 1, IsBit: true},
+	{Code: &DataRegister, Address: 200, Length: 2},
+}
+
+// multiBlockItems adjacent words are merged, bits and distant words are blocks of their own
+var multiBlockItems = []BatchItem{
+	{Code: &DataRegister, Address: 100, Length: 1},
+	{Code: &InternalRelay, Address: 0, Length: 1, IsBit: true},
+	{Code: &DataRegister, Address: 101, Length: 2},
+	{Code: &InternalRelay, Address: 5, Length: 1, IsBit: true},
+	{Code: &DataRegister, Address: 300, Length: 1},
+}
+
+func TestPlanRandomRead(t *testing.T) {
+	q := &QnaProtocolStack{}
+	words, dwords := q.planRandomRead(randomItems)
+	wantWords := []batchDevice{{code: &DataRegister, address: 100, length: 1}, {code: &InternalRelay, address: 100, length: 1}}
+	wantDwords := []batchDevice{{code: &DataRegister, address: 200, length: 2}}
+	if !reflect.DeepEqual(words, wantWords) || !reflect.DeepEqual(dwords, wantDwords) {
+		t.Fatalf("planRandomRead() = %+v %+v, want %+v %+v", words, dwords, wantWords, wantDwords)
+	}
+	want := batchLayout{words: [][]int{{0}, {1}, {2, 3}}, bits: []int{-1, 0, -1}, count: 4, dwordStart: 2}
+	if !reflect.DeepEqual(q.batch, want) {
+		t.Fatalf("layout = %+v, want %+v", q.batch, want)
+	}
+}
+
+func TestPlanMultiBlockRead(t *testing.T) {
+	q := &QnaProtocolStack{}
+	wordBlocks, bitBlocks := q.planMultiBlockRead(multiBlockItems)
+	wantWordBlocks := []batchDevice{{code: &DataRegister, address: 100, length: 3}, {code: &DataRegister, address: 300, length: 1}}
+	wantBitBlocks := []batchDevice{{code: &InternalRelay, address: 0, length: 1, isBit: true}, {code: &InternalRelay, address: 5, length: 1, isBit: true}}
+	if !reflect.DeepEqual(wordBlocks, wantWordBlocks) || !reflect.DeepEqual(bitBlocks, wantBitBlocks) {
+		t.Fatalf("planMultiBlockRead() = %+v %+v, want %+v %+v", wordBlocks, bitBlocks, wantWordBlocks, wantBitBlocks)
+	}
+	want := batchLayout{words: [][]int{{0}, {4}, {1, 2}, {5}, {3}}, bits: []int{-1, 0, -1, 0, -1}, count: 6, dwordStart: 6}
+	if !reflect.DeepEqual(q.batch, want) {
+		t.Fatalf("layout = %+v, want %+v", q.batch, want)
+	}
+}
+
+func TestPlanMultiBlockReadBits(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []BatchItem
+		wantBlock []batchDevice
+		wantWords [][]int
+		wantBits  []int
+	}{
+		{
+			name: "adjacent bits share a block",
+			items: []BatchItem{
+				{Code: &InternalRelay, Address: 0, IsBit: true},
+				{Code: &InternalRelay, Address: 1, IsBit: true},
+			},
+			wantBlock: []batchDevice{{code: &InternalRelay, address: 0, length: 2, isBit: true}},
+			wantWords: [][]int{{0}, {0}},
+			wantBits:  []int{0, 1},
+		},
+		{
+			name: "bit 17 of a block is in its second word",
+			items: []BatchItem{
+				{Code: &Input, Address: 0, IsBit: true},
+				{Code: &Input, Address: 1, IsBit: true},
+				{Code: &Input, Address: 2, IsBit: true},
+				{Code: &Input, Address: 3, IsBit: true},
+				{Code: &Input, Address: 4, IsBit: true},
+				{Code: &Input, Address: 5, IsBit: true},
+				{Code: &Input, Address: 6, IsBit: true},
+				{Code: &Input, Address: 7, IsBit: true},
+				{Code: &Input, Address: 8, IsBit: true},
+				{Code: &Input, Address: 9, IsBit: true},
+				{Code: &Input, Address: 10, IsBit: true},
+				{Code: &Input, Address: 11, IsBit: true},
+				{Code: &Input, Address: 12, IsBit: true},
+				{Code: &Input, Address: 13, IsBit: true},
+				{Code: &Input, Address: 14, IsBit: true},
+				{Code: &Input, Address: 15, IsBit: true},
+				{Code: &Input, Address: 16, IsBit: true},
+				{Code: &Input, Address: 17, IsBit: true},
+			},
+			wantBlock: []batchDevice{{code: &Input, address: 0, length: 18, isBit: true}},
+			wantWords: [][]int{{0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {1}, {1}},
+			wantBits:  []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &QnaProtocolStack{}
+			wordBlocks, bitBlocks := q.planMultiBlockRead(tt.items)
+			if len(wordBlocks) != 0 || !reflect.DeepEqual(bitBlocks, tt.wantBlock) {
+				t.Fatalf("planMultiBlockRead() = %+v %+v, want %+v", wordBlocks, bitBlocks, tt.wantBlock)
+			}
+			if !reflect.DeepEqual(q.batch.words, tt.wantWords) || !reflect.DeepEqual(q.batch.bits, tt.wantBits) {
+				t.Fatalf("layout = %+v, want words %v bits %v", q.batch, tt.wantWords, tt.wantBits)
+			}
+		})
+	}
+}
+
+func TestBatchRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		reader   BatchReader
+		read     func(reader BatchReader) []byte
+		request  []byte
+		response []byte
+		want     [][]byte
+	}{
+		{
+			name:     "binary random read",
+			reader:   NewQna3eBinary().(BatchReader),
+			read:     func(reader BatchReader) []byte { return reader.RandomRead(randomItems) },
+			request:  decodeHex(t, "5000 00 FF FF03 00 1400 1000 0304 0000 02 01 640000A8 64000090 C80000A8"),
+			response: decodeHex(t, "D000 00 FF FF03 00 0A00 0000 3412 0100 7856 3412"),
+			want:     [][]byte{{0x12, 0x34}, {0x01}, {0x12, 0x34, 0x56, 0x78}},
+		},
+		{
+			name:     "ascii random read",
+			reader:   NewQna3eASCII().(BatchReader),
+			read:     func(reader BatchReader) []byte { return reader.RandomRead(randomItems) },
+			request:  []byte("500000FF03FF00" + "0028" + "0010" + "0403" + "0000" + "0201" + "D*000100" + "M*000100" + "D*000200"),
+			response: []byte("D00000FF03FF00" + "0014" + "0000" + "1234" + "0001" + "12345678"),
+			want:     [][]byte{{0x12, 0x34}, {0x01}, {0x56, 0x78, 0x12, 0x34}},
+		},
+		{
+			name:   "binary multi-block read",
+			reader: NewQna3eBinary().(BatchReader),
+			read:   func(reader BatchReader) []byte { return reader.MultiBlockRead(multiBlockItems) },
+			request: decodeHex(t, "5000 00 FF FF03 00 2000 1000 0604 0000 02 02 "+
+				"640000A8 0300 2C0100A8 0100 00000090 0100 05000090 0100"),
+			response: decodeHex(t, "D000 00 FF FF03 00 0E00 0000 0100 7856 3412 FF00 0100 0000"),
+			want:     [][]byte{{0x00, 0x01}, {0x01}, {0x12, 0x34, 0x56, 0x78}, {0x00}, {0x00, 0xFF}},
+		},
+		{
+			name:     "ascii multi-block read",
+			reader:   NewQna3eASCII().(BatchReader),
+			read:     func(reader BatchReader) []byte { return reader.MultiBlockRead(multiBlockItems) },
+			request:  []byte("500000FF03FF00" + "0040" + "0010" + "0406" + "0000" + "0202" + "D*0001000003" + "D*0003000001" + "M*0000000001" + "M*0000050001"),
+			response: []byte("D00000FF03FF00" + "001C" + "0000" + "0001" + "5678" + "1234" + "00FF" + "0001" + "0000"),
+			want:     [][]byte{{0x00, 0x01}, {0x01}, {0x56, 0x78, 0x12, 0x34}, {0x00}, {0x00, 0xFF}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.read(tt.reader); !bytes.Equal(got, tt.request) {
+				t.Fatalf("request = % X, want % X", got, tt.request)
+			}
+			got, err := tt.reader.ParseBatch(tt.response)
+			if err != nil {
+				t.Fatalf("ParseBatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseBatch() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBatchErrors(t *testing.T) {
+	q := NewQna3eBinary().(BatchReader)
+	q.RandomRead(randomItems)
+	if _, err := q.ParseBatch(decodeHex(t, "D000 00 FF FF03 00 0200 5DC0")); err != EndCodeError(0xC05D) {
+		t.Fatalf("ParseBatch() error = %v, want end code C05D", err)
+	}
+	if _, err := q.ParseBatch(decodeHex(t, "D000 00 FF FF03 00 0600 0000 3412 0100")); err == nil {
+		t.Fatal("ParseBatch() accepted a response missing the double word")
+	}
+}
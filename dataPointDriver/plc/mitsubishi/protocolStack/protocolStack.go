@@ -49,8 +49,10 @@ var (
 )
 
 const (
-	readBatch  commandType = 0x0401
-	writeBatch commandType = 0x1401
+	readBatch      commandType = 0x0401
+	writeBatch     commandType = 0x1401
+	readRandom     commandType = 0x0403
+	readMultiBlock commandType = 0x0406
 )
 const (
 	childCommandBit  childCommandType = 0x0001
@@ -73,6 +75,7 @@ type QnaProtocolStack struct {
 	command             commandType
 	childCommand        childCommandType
 	data1               readVar
+	batch               batchLayout // the items of the last random or multi-block read
 }
 
 type Qna3EAsciiProtocolStack struct {
@@ -82,6 +85,30 @@ type Qna3EAsciiProtocolStack struct {
 type Qna3EBinaryProtocolStack struct {
 	QnaProtocolStack
 }
+
+// Qna1EBinaryProtocolStack MC protocol 1E frame of A series and FX3U-ENET, only the connected station is accessible
+type Qna1EBinaryProtocolStack struct {
+	pcNum         byte
+	monitorTimer  uint16
+	isBit         bool
+	responseCount int
+}
+type ComputerLinkFormat int
+
+const (
+	ComputerLinkFormat1 ComputerLinkFormat = 1
+	ComputerLinkFormat4 ComputerLinkFormat = 4 // format 1 with CR LF at the end of every frame
+)
+
+// ComputerLink dedicated protocol of the FX-485BD and the QJ71C24 in A compatible 1C frames
+type ComputerLink struct {
+	format        ComputerLinkFormat
+	station       int
+	pcNum         byte
+	messageWait   byte // in units of 10ms
+	isBit         bool
+	responseCount int
+}
 type ProgramPort struct {
 }
 
@@ -91,6 +118,23 @@ type Qna interface {
 	Parse(input []byte) ([]byte, error)
 }
 
+// BatchReader 3E frames reading several variables in one request
+type BatchReader interface {
+	Qna
+	// RandomRead 0x0403 reads every item as words and double words
+	RandomRead(items []BatchItem) []byte
+	// MultiBlockRead 0x0406 merges the adjacent items of the same device into blocks
+	MultiBlockRead(items []BatchItem) []byte
+	// ParseBatch returns the data of every item in the form Parse returns for a single read, bit items as 0 or 1
+	ParseBatch(input []byte) ([][]byte, error)
+}
+
+// Station serial protocols addressing several plc on the same bus by station number
+type Station interface {
+	Qna
+	SetStation(station int)
+}
+
 func NewQna3eASCII() Qna {
 	q := Qna3EAsciiProtocolStack{QnaProtocolStack{
 		deputyHeader: 0x5000, networkNum: 0x00, plcNum: 0xff, targetIONum: 0x03FF, targetModuleStation: 0x00,
@@ -106,6 +150,20 @@ func NewQna3eBinary() Qna {
 	return &q
 }
 
+func NewQna1eBinary() Qna {
+	q := Qna1EBinaryProtocolStack{pcNum: 0xff, monitorTimer: 0x000A}
+	return &q
+}
+
+// NewComputerLink format 1 is used unless format 4 is specified
+func NewComputerLink(format ComputerLinkFormat) Station {
+	c := ComputerLink{format: ComputerLinkFormat1, pcNum: 0xff}
+	if format == ComputerLinkFormat4 {
+		c.format = ComputerLinkFormat4
+	}
+	return &c
+}
+
 func NewProgramPort() Qna {
 	p := ProgramPort{}
 	return &p
@@ -0,0 +1,24 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func TestQna3eBinaryReadVar(t *testing.T) {
+	want := decodeHex(t, "5000 00 FF FF03 00 0C00 1000 0104 0000 640000 A8 0300")
+	if got := NewQna3eBinary().ReadVar(false, &DataRegister, 100, 3); !bytes.Equal(got, want) {
+		t.Fatalf("ReadVar() = % X, want % X", got, want)
+	}
+}
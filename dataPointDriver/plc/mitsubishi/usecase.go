@@ -1,37 +1,35 @@
 package mitsubishi
 
 import (
+	"didaGatewayCenter/dataPointDriver/connection"
 	"didaGatewayCenter/dataPointDriver/plc/mitsubishi/protocolStack"
+	"didaGatewayCenter/dataTransform"
 	"didaGatewayCenter/domain"
 	"didaGatewayCenter/net"
 	"errors"
 	"fmt"
-	"github.com/goburrow/serial"
 	"go.uber.org/zap"
-	"io"
-	net2 "net"
-	"os"
-	"reflect"
-	"runtime"
 	"sync"
-	"syscall"
 	"time"
 )
 
 type mitsubishi struct {
-	q            protocolStack.Qna
-	isConnected  bool
-	iLogU        domain.ILogUsecase
-	iDTU         domain.IDataTransformUsecase
-	conn         domain.Software
-	portConfig   *domain.DataPointPortConfig
-	timeoutCount int
-	lock         sync.Mutex
+	q           protocolStack.Qna
+	isConnected bool
+	iLogU       domain.ILogUsecase
+	iDTU        domain.IDataTransformUsecase
+	conn        domain.Software
+	portConfig  *domain.DataPointPortConfig
+	monitor     connection.Monitor
+	policy      connection.Policy
+	lock        sync.Mutex
 }
 
 func (s *mitsubishi) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
 	s.portConfig = portConfig
 	s.iDTU = transform
+	s.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	s.monitor.SetMaxTimeouts(s.policy.MaxTimeouts)
 
 	go func() {
 		s.connect()
@@ -43,12 +41,13 @@ func (s *mitsubishi) Init(portConfig *domain.DataPointPortConfig, transform doma
 func (s *mitsubishi) connect() {
 
 	address := fmt.Sprintf("%s:%d", s.portConfig.Param.IP, s.portConfig.Param.PortNumber)
-	//	interval := 0
 	switch s.portConfig.DeviceType {
 	case domain.DeviceTypeMitsubishiProgramPort:
 		s.q = protocolStack.NewProgramPort()
 	case domain.DeviceTypeMitsubishiComputerLink:
+		s.q = protocolStack.NewComputerLink(protocolStack.ComputerLinkFormat(s.portConfig.Param.ComputerLinkFormat))
 	case domain.DeviceTypeMcBinaryQna1E:
+		s.q = protocolStack.NewQna1eBinary()
 	case domain.DeviceTypeMCBinaryQna3E:
 		s.q = protocolStack.NewQna3eBinary()
 	case domain.DeviceTypeMCAsciiQna3E:
@@ -56,175 +55,90 @@ func (s *mitsubishi) connect() {
 	}
 	s.iLogU.GetLogger().Info("mitsubishi plc is connecting", zap.String("portName", s.portConfig.PortName))
 	if s.portConfig.PortType == domain.SerialType {
-		param := s.portConfig.Param
-		portNode := ""
-		if runtime.GOOS == "windows" {
-			portNode = fmt.Sprintf("\\\\.\\COM%d", param.COM)
-		} else {
-			portNode = fmt.Sprintf("/dev/COM%d", param.COM)
-		}
-		c := serial.Config{
-			Address:  portNode,
-			BaudRate: param.BandRate,
-			DataBits: param.DateBits,
-			StopBits: param.StopBit,
-			Parity:   string(param.Parity[0]),
-			Timeout:  time.Millisecond * 50,
-		}
-		fb, err := serial.Open(&c)
-		if err != nil {
-			s.iLogU.GetLogger().Warn("Failed to open serial port", zap.String("portName", s.portConfig.PortName), zap.String("node", portNode), zap.Error(err))
-			return
+		for {
+			if s.isConnected {
+				time.Sleep(time.Second)
+				continue
+			}
+			// the port is reopened after too many timeouts in a row
+			s.lock.Lock()
+			net.Close(s.conn)
+			s.lock.Unlock()
+			port := connection.OpenSerial(s.portConfig, time.Millisecond*50, s.policy, s.iLogU.GetLogger())
+			s.lock.Lock()
+			s.conn = net.New(port)
+			s.lock.Unlock()
+			s.isConnected = true
+			s.monitor.Connected()
 		}
-		s.isConnected = true
-		s.iLogU.GetLogger().Info("mitsubishi serial port connected", zap.String("portName", s.portConfig.PortName), zap.String("node", portNode))
-		ss := net.New(fb)
-		s.conn = ss
 	} else {
+		logger := s.iLogU.GetLogger()
+		backoff := connection.NewBackoff(s.policy)
 		for {
 			if s.isConnected {
 				time.Sleep(time.Second)
 				continue
 			}
-			/*interval++
-			if interval >= 30 {
-				interval = 30
-			}
-			time.Sleep(time.Duration(interval) * time.Second)*/
-			tcpConn, err := net.Dial("tcp", address)
+			s.lock.Lock()
+			net.Close(s.conn)
+			s.lock.Unlock()
+			tcpConn, err := net.Dial("tcp", address, s.policy.DialTimeout)
 			if err != nil {
-				time.Sleep(time.Second)
-				//	s.iLogU.GetLogger().Warn("cannot connect to mitsubishi plc", zap.String("name", s.portConfig.PortName), zap.String("address", address), zap.Error(err))
+				backoff.Wait(logger, "cannot connect to mitsubishi plc", err, zap.String("name", s.portConfig.PortName), zap.String("address", address))
 				continue
 			}
-			s.iLogU.GetLogger().Info("mitsubishi plc connected", zap.String("name", s.portConfig.PortName), zap.String("address", address))
-			//	interval = 0
+			s.iLogU.GetLogger().Info("mitsubishi plc connected", zap.String("name", s.portConfig.PortName), zap.String("address", address),
+				zap.Int("attempts", backoff.Attempts()+1))
+			backoff.Reset()
+			s.lock.Lock()
 			s.conn = tcpConn
+			s.lock.Unlock()
 			s.isConnected = true
+			s.monitor.Connected()
 		}
 	}
 }
-func (s *mitsubishi) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) domain.IValueType {
+func (s *mitsubishi) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
 	if !s.isConnected {
 		time.Sleep(time.Second)
-		return nil
+		return nil, connection.ErrNotConnected
 	}
-	dataType := variableList.DataType
-	regType := variableList.Param.RegType
 	regAddr := variableList.Param.RegAddr
-	length := 0
-	isBit := false
-	var code *protocolStack.RegCode
-	switch dataType {
-	case domain.VarDataTypeBool:
-		length = 1
-		isBit = true
-	case domain.VarDataTypeBit:
-		length = 1
-	case domain.VarDataTypeUint16, domain.VarDataTypeInt16:
-		length = 1
-	case domain.VarDataTypeUint32, domain.VarDataTypeInt32, domain.VarDataTypeFloat:
-		length = 2
-	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
-		length = 4
+	length, isBit := getLength(variableList)
+	code := getCode(portInfo, variableList)
+	if code == nil {
+		return nil, connection.InvalidAddress("register type %d of %s is not a mitsubishi device", variableList.Param.RegType, variableList.Name)
 	}
-	if portInfo.DeviceType == domain.DeviceTypeMitsubishiProgramPort {
-		switch regType {
-		case domain.RegTypeMitsubishiXRegister:
-			code = &protocolStack.SerialInput
-		case domain.RegTypeMitsubishiYRegister:
-			code = &protocolStack.SerialOutput
-		case domain.RegTypeMitsubishiMRegister:
-			code = &protocolStack.SerialSpecialRelay
-		case domain.RegTypeMitsubishiSRegister:
-			code = &protocolStack.SerialStatus
-		case domain.RegTypeMitsubishiTRegister:
-			code = &protocolStack.SerialTimerCoil
-		case domain.RegTypeMitsubishiCRegister:
-			code = &protocolStack.SerialCounterCoil
-		case domain.RegTypeMitsubishiDRegister:
-			code = &protocolStack.SerialData
-		case domain.RegTypeMitsubishiTVRegister:
-			code = &protocolStack.SerialTimerValue
-		case domain.RegTypeMitsubishiCVRegister:
-			code = &protocolStack.SerialCounter16Value
-			if variableList.Param.RegAddr > 200 {
-				code = &protocolStack.SerialCounter32Value
-			}
-		}
-	} else {
-		switch regType {
-		case domain.RegTypeMitsubishiXRegister:
-			code = &protocolStack.Input
-		case domain.RegTypeMitsubishiYRegister:
-			code = &protocolStack.Output
-		case domain.RegTypeMitsubishiMRegister:
-			code = &protocolStack.InternalRelay
-		case domain.RegTypeMitsubishiSRegister:
-			code = &protocolStack.SpecialRelay
-		case domain.RegTypeMitsubishiTRegister:
-			code = &protocolStack.TimerCoil
-		case domain.RegTypeMitsubishiCRegister:
-			code = &protocolStack.CounterCoil
-		case domain.RegTypeMitsubishiDRegister:
-			code = &protocolStack.DataRegister
-		case domain.RegTypeMitsubishiTVRegister:
-			code = &protocolStack.TimerCurrent
-		case domain.RegTypeMitsubishiCVRegister:
-			code = &protocolStack.CounterCurrent
-		}
-	}
-
-	bb := s.q.ReadVar(isBit, code, regAddr, length)
 
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	r, err := s.conn.WriteReadTimeout(bb, time.Second)
+	s.setStation(deviceInfo)
+	bb := s.q.ReadVar(isBit, code, regAddr, length)
+	if bb == nil {
+		s.iLogU.GetLogger().Warn("invalid variable config", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name))
+		return nil, connection.InvalidAddress("invalid variable config of %s", variableList.Name)
+	}
 
+	s.monitor.Begin()
+	r, err := s.conn.WriteReadTimeout(bb, connection.RespTimeout(portInfo.Param))
 	if err != nil {
-		if errors.Is(err, os.ErrDeadlineExceeded) {
-			s.timeoutCount++
-			if s.timeoutCount == 5 {
-				s.timeoutCount = 0
-				s.isConnected = false
-				s.iLogU.GetLogger().Warn("mitsubishi timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-			} else {
-				s.iLogU.GetLogger().Warn("read from mitsubishi timeout", zap.String("portName", portInfo.PortName),
-					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-			}
-			return nil
-		}
-		if opErr, ok := err.(*net2.OpError); ok {
-			switch t := opErr.Err.(type) {
-			case *os.SyscallError:
-				if errno, ok := t.Err.(syscall.Errno); ok {
-					switch errno {
-					case syscall.ECONNABORTED:
-						s.isConnected = false
-						s.iLogU.GetLogger().Warn("mitsubishi connection aborted,reconnecting")
-					default:
-						s.iLogU.GetLogger().Warn("unexpected syscall error occurred while reading mitsubishi", zap.String("portName", portInfo.PortName),
-							zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-					}
-				}
-			default:
-				s.iLogU.GetLogger().Warn("unknown operation error", zap.String("error type", reflect.ValueOf(opErr.Err).String()), zap.Error(err))
-			}
-		} else {
-			s.iLogU.GetLogger().Warn("error occurred while reading mitsubishi", zap.String("portName", portInfo.PortName),
-				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-		}
+		return nil, s.checkError(portInfo, deviceInfo, variableList.Name, err)
 	}
 
 	valueByte, err := s.q.Parse(r)
 	if err != nil {
 		s.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-		return nil
+		return nil, parseError(err)
 	}
+	s.monitor.Succeeded()
 	if len(valueByte) == 1 {
 		valueByte = append([]byte{0}, valueByte[0])
 	}
+	if variableList.DataType == domain.VarDataTypeString {
+		valueByte = s.storedOrder(valueByte)
+	}
 	switch code {
 	case &protocolStack.SerialInput, &protocolStack.SerialOutput:
 		variableList.DataType = domain.VarDataTypeBit
@@ -239,14 +153,14 @@ func (s *mitsubishi) Read(portInfo *domain.DataPointPortConfig, deviceInfo *doma
 	if err != nil {
 		s.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
 	}
-	return value
+	return value, nil
 }
 
 func (s *mitsubishi) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
 	if !s.isConnected {
-		time.Sleep(time.Second)
-		return nil
+		return connection.ErrNotConnected
 	}
 	dataType := variableInfo.DataType
 	regType := variableInfo.Param.RegType
@@ -266,6 +180,8 @@ func (s *mitsubishi) Write(portInfo *domain.DataPointPortConfig, deviceInfo *dom
 		length = 2
 	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
 		length = 4
+	case domain.VarDataTypeString:
+		length = (dataTransform.StringSize(variableInfo) + 1) / 2
 	}
 	if portInfo.DeviceType == domain.DeviceTypeMitsubishiProgramPort {
 		switch regType {
@@ -295,8 +211,12 @@ func (s *mitsubishi) Write(portInfo *domain.DataPointPortConfig, deviceInfo *dom
 			code = &protocolStack.SerialCounter16Value
 		}
 		if variableInfo.DataType == domain.VarDataTypeBit {
-			v := s.Read(portInfo, deviceInfo, variableInfo).OriginalValue()
-			if inputValue.(float64) != 0 {
+			current, err := s.Read(portInfo, deviceInfo, variableInfo)
+			if err != nil {
+				return err
+			}
+			v := current.OriginalValue()
+			if dataTransform.NewValue(inputValue).ToBool() {
 				inputValue = float64(v | (1 << variableInfo.Param.BitAddr))
 			} else {
 				inputValue = float64(v & uint16(^(0x0001 << variableInfo.Param.BitAddr)))
@@ -325,59 +245,174 @@ func (s *mitsubishi) Write(portInfo *domain.DataPointPortConfig, deviceInfo *dom
 		}
 	}
 
-	result, err := s.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue.(float64))
+	if code == nil {
+		return connection.InvalidAddress("register type %d of %s is not a mitsubishi device", regType, variableInfo.Name)
+	}
+	result, err := s.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue)
 	if err != nil {
-		panic(err)
+		return connection.InvalidAddress("%v", err)
 	}
 	if dataType == domain.VarDataTypeBit {
 		result = []byte{result[1]}
 	}
-	r1 := s.q.WriteVar(isBit, code, regAddr, length, result)
+	if dataType == domain.VarDataTypeString {
+		if len(result)%2 != 0 {
+			result = append(result, 0)
+		}
+		// the stacks take the highest word first like the numbers
+		reverse(result)
+	}
 
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	r, err := s.conn.WriteReadTimeout(r1, time.Second)
+	s.setStation(deviceInfo)
+	r1 := s.q.WriteVar(isBit, code, regAddr, length, result)
+	if r1 == nil {
+		return connection.InvalidAddress("invalid variable config of %s", variableInfo.Name)
+	}
+	s.monitor.Begin()
+	r, err := s.conn.WriteReadTimeout(r1, connection.RespTimeout(portInfo.Param))
 	if err != nil {
-		if errors.Is(err, os.ErrDeadlineExceeded) {
-			s.timeoutCount++
-			if s.timeoutCount == 5 {
-				s.timeoutCount = 0
-				s.isConnected = false
-				s.iLogU.GetLogger().Warn("mitsubishi timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-			} else {
-				s.iLogU.GetLogger().Warn("read from mitsubishi timeout", zap.String("portName", portInfo.PortName),
-					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-			}
-			return nil
-		} else if errors.Is(err, io.EOF) {
-			s.isConnected = false
-			s.iLogU.GetLogger().Warn("mitsubishi timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-		}
-		if opErr, ok := err.(*net2.OpError); ok {
-			switch t := opErr.Err.(type) {
-			case *os.SyscallError:
-				if errno, ok := t.Err.(syscall.Errno); ok {
-					switch errno {
-					case syscall.ECONNABORTED, io.EOF:
-						s.isConnected = false
-						s.iLogU.GetLogger().Warn("mitsubishi connection aborted,reconnecting")
-					default:
-						s.iLogU.GetLogger().Warn("unexpected syscall error occurred while reading mitsubishi", zap.String("portName", portInfo.PortName),
-							zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-					}
-				}
-			default:
-				s.iLogU.GetLogger().Warn("unknown operation error", zap.String("error type", reflect.ValueOf(opErr.Err).String()), zap.Error(err))
-			}
-		} else {
-			s.iLogU.GetLogger().Warn("error occurred while reading mitsubishi", zap.String("portName", portInfo.PortName),
-				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-		}
+		return s.checkError(portInfo, deviceInfo, variableInfo.Name, err)
 	}
 	if _, err := s.q.Parse(r); err != nil {
 		s.iLogU.GetLogger().Warn("mitsubishi write failed", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name),
 			zap.String("result", fmt.Sprintf("% X", r)), zap.Error(err))
+		return parseError(err)
+	}
+	s.monitor.Succeeded()
+	return nil
+}
+
+// checkError classifies and logs the error of a request, the connection or the serial port is reopened when it is lost or
+// times out too often
+func (s *mitsubishi) checkError(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableName string, err error) error {
+	driverErr := connection.Classify(err)
+	if s.monitor.Failed(driverErr) {
+		s.isConnected = false
+		s.monitor.Disconnected()
+		s.iLogU.GetLogger().Warn("mitsubishi connection lost, reconnecting", zap.String("portName", portInfo.PortName), zap.Error(driverErr))
+		return driverErr
+	}
+	s.iLogU.GetLogger().Warn("mitsubishi request failed", zap.String("portName", portInfo.PortName),
+		zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableName), zap.Error(driverErr))
+	return driverErr
+}
+
+// parseError the end codes of a response are exceptions of the plc
+func parseError(err error) error {
+	var endCodeErr protocolStack.EndCodeError
+	if errors.As(err, &endCodeErr) {
+		return connection.Exception(int(endCodeErr), err)
+	}
+	return connection.InvalidResponse(err)
+}
+
+// Health the health of the connection of the port
+func (s *mitsubishi) Health() domain.PortHealth {
+	return s.monitor.Health()
+}
+
+// Transmit sends a raw frame on the serial bus between the requests of the cycle sample
+func (s *mitsubishi) Transmit(request []byte, timeout time.Duration) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.isConnected {
+		return nil, connection.ErrNotConnected
+	}
+	return s.conn.WriteReadTimeout(request, timeout)
+}
+
+// setStation serial links address the plc by the station number of the device
+func (s *mitsubishi) setStation(deviceInfo *domain.DeviceList) {
+	if station, ok := s.q.(protocolStack.Station); ok {
+		station.SetStation(deviceInfo.DevAddr)
+	}
+}
+
+// storedOrder the characters of a string variable as they are stored in the plc, the low byte of a word first,
+// the 3E ASCII frames give the words in address order and the others the highest word first
+func (s *mitsubishi) storedOrder(data []byte) []byte {
+	if _, ok := s.q.(*protocolStack.Qna3EAsciiProtocolStack); ok {
+		for i := 0; i+1 < len(data); i += 2 {
+			data[i], data[i+1] = data[i+1], data[i]
+		}
+		return data
+	}
+	reverse(data)
+	return data
+}
+
+func reverse(data []byte) {
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+}
+
+// getLength points of a single read, bool variables are read in bit units and strings in words
+func getLength(variableList *domain.DataPointVariableList) (length int, isBit bool) {
+	switch variableList.DataType {
+	case domain.VarDataTypeBool:
+		return 1, true
+	case domain.VarDataTypeBit, domain.VarDataTypeUint16, domain.VarDataTypeInt16:
+		return 1, false
+	case domain.VarDataTypeUint32, domain.VarDataTypeInt32, domain.VarDataTypeFloat:
+		return 2, false
+	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
+		return 4, false
+	case domain.VarDataTypeString:
+		return (dataTransform.StringSize(variableList) + 1) / 2, false
+	}
+	return 0, false
+}
+
+func getCode(portInfo *domain.DataPointPortConfig, variableList *domain.DataPointVariableList) *protocolStack.RegCode {
+	if portInfo.DeviceType == domain.DeviceTypeMitsubishiProgramPort {
+		switch variableList.Param.RegType {
+		case domain.RegTypeMitsubishiXRegister:
+			return &protocolStack.SerialInput
+		case domain.RegTypeMitsubishiYRegister:
+			return &protocolStack.SerialOutput
+		case domain.RegTypeMitsubishiMRegister:
+			return &protocolStack.SerialSpecialRelay
+		case domain.RegTypeMitsubishiSRegister:
+			return &protocolStack.SerialStatus
+		case domain.RegTypeMitsubishiTRegister:
+			return &protocolStack.SerialTimerCoil
+		case domain.RegTypeMitsubishiCRegister:
+			return &protocolStack.SerialCounterCoil
+		case domain.RegTypeMitsubishiDRegister:
+			return &protocolStack.SerialData
+		case domain.RegTypeMitsubishiTVRegister:
+			return &protocolStack.SerialTimerValue
+		case domain.RegTypeMitsubishiCVRegister:
+			if variableList.Param.RegAddr > 200 {
+				return &protocolStack.SerialCounter32Value
+			}
+			return &protocolStack.SerialCounter16Value
+		}
+		return nil
+	}
+	switch variableList.Param.RegType {
+	case domain.RegTypeMitsubishiXRegister:
+		return &protocolStack.Input
+	case domain.RegTypeMitsubishiYRegister:
+		return &protocolStack.Output
+	case domain.RegTypeMitsubishiMRegister:
+		return &protocolStack.InternalRelay
+	case domain.RegTypeMitsubishiSRegister:
+		return &protocolStack.SpecialRelay
+	case domain.RegTypeMitsubishiTRegister:
+		return &protocolStack.TimerCoil
+	case domain.RegTypeMitsubishiCRegister:
+		return &protocolStack.CounterCoil
+	case domain.RegTypeMitsubishiDRegister:
+		return &protocolStack.DataRegister
+	case domain.RegTypeMitsubishiTVRegister:
+		return &protocolStack.TimerCurrent
+	case domain.RegTypeMitsubishiCVRegister:
+		return &protocolStack.CounterCurrent
 	}
 	return nil
 }
@@ -0,0 +1,143 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	finsTcpHeaderLength = 16
+	finsHeaderLength    = 10
+
+	finsTcpCommandNodeAddressRequest  = 0
+	finsTcpCommandNodeAddressResponse = 1
+	finsTcpCommandFrameSend           = 2
+)
+
+var finsMagic = []byte{'F', 'I', 'N', 'S'}
+
+// ShakeHands builds the node address data send frame, client node 0 lets the plc assign one automatically
+func (f *FinsTcp) ShakeHands() []byte {
+	result := make([]byte, 20)
+	copy(result, finsMagic)
+	binary.BigEndian.PutUint32(result[4:8], 12)
+	binary.BigEndian.PutUint32(result[8:12], finsTcpCommandNodeAddressRequest)
+	binary.BigEndian.PutUint32(result[12:16], 0)
+	binary.BigEndian.PutUint32(result[16:20], uint32(f.clientNode))
+	return result
+}
+
+// ParseShakeHands stores the client and server node addresses assigned by the plc
+func (f *FinsTcp) ParseShakeHands(input []byte) error {
+	if len(input) < 24 {
+		return fmt.Errorf("invalid length: %d", len(input))
+	}
+	if !bytes.Equal(input[0:4], finsMagic) {
+		return fmt.Errorf("invalid header: % X", input[0:4])
+	}
+	command := binary.BigEndian.Uint32(input[8:12])
+	errorCode := binary.BigEndian.Uint32(input[12:16])
+	if errorCode != 0 {
+		return fmt.Errorf("node address request rejected, error code: %d", errorCode)
+	}
+	if command != finsTcpCommandNodeAddressResponse {
+		return fmt.Errorf("unexpected command: %d", command)
+	}
+	f.clientNode = input[19]
+	f.serverNode = input[23]
+	f.header.sa1 = f.clientNode
+	f.header.da1 = f.serverNode
+	return nil
+}
+
+func (f *FinsTcp) ReadVar(isBit bool, area *AreaCode, address int, bitAddress int, length int) []byte {
+	command := make([]byte, 2)
+	binary.BigEndian.PutUint16(command, uint16(memoryAreaRead))
+	result := append(command, memoryAreaParam(isBit, area, address, bitAddress, length)...)
+	return f.frame(result)
+}
+
+func (f *FinsTcp) WriteVar(isBit bool, area *AreaCode, address int, bitAddress int, length int, data []byte) []byte {
+	command := make([]byte, 2)
+	binary.BigEndian.PutUint16(command, uint16(memoryAreaWrite))
+	result := append(command, memoryAreaParam(isBit, area, address, bitAddress, length)...)
+	result = append(result, data...)
+	return f.frame(result)
+}
+
+func (f *FinsTcp) Parse(input []byte) ([]byte, error) {
+	if len(input) < finsTcpHeaderLength {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if !bytes.Equal(input[0:4], finsMagic) {
+		return nil, fmt.Errorf("invalid header: % X", input[0:4])
+	}
+	length := binary.BigEndian.Uint32(input[4:8])
+	if len(input) != int(length)+8 {
+		return nil, fmt.Errorf("incomplete data,require %d but got %d", length+8, len(input))
+	}
+	if errorCode := binary.BigEndian.Uint32(input[12:16]); errorCode != 0 {
+		return nil, fmt.Errorf("fins/tcp error code: %d", errorCode)
+	}
+	return parseFinsResponse(input[finsTcpHeaderLength:], finsHeaderLength)
+}
+
+// frame wraps the fins command with the fins header and the fins/tcp header
+func (f *FinsTcp) frame(command []byte) []byte {
+	f.header.sid++
+	h := f.header
+	fins := []byte{h.icf, h.rsv, h.gct, h.dna, h.da1, h.da2, h.sna, h.sa1, h.sa2, h.sid}
+	fins = append(fins, command...)
+
+	result := make([]byte, finsTcpHeaderLength)
+	copy(result, finsMagic)
+	binary.BigEndian.PutUint32(result[4:8], uint32(len(fins)+8))
+	binary.BigEndian.PutUint32(result[8:12], finsTcpCommandFrameSend)
+	binary.BigEndian.PutUint32(result[12:16], 0)
+	return append(result, fins...)
+}
+
+// memoryAreaParam area code(1) + word address(2) + bit address(1) + number of items(2)
+func memoryAreaParam(isBit bool, area *AreaCode, address int, bitAddress int, length int) []byte {
+	result := make([]byte, 6)
+	result[0] = area.wordCode
+	if isBit {
+		result[0] = area.bitCode
+	} else {
+		bitAddress = 0
+	}
+	binary.BigEndian.PutUint16(result[1:3], uint16(address+area.offset))
+	result[3] = byte(bitAddress)
+	binary.BigEndian.PutUint16(result[4:6], uint16(length))
+	return result
+}
+
+// parseFinsResponse checks the end code of a fins response and returns the data behind it
+func parseFinsResponse(input []byte, headerLength int) ([]byte, error) {
+	if len(input) < headerLength+4 {
+		return nil, fmt.Errorf("invalid length of fins response: %d", len(input))
+	}
+	if input[0]&0x40 == 0 {
+		return nil, fmt.Errorf("not a fins response, icf: %X", input[0])
+	}
+	// the highest bit of MRES means relay error, the highest two bits of SRES mean plc fatal/non-fatal errors
+	endCode := uint16(input[headerLength+2]&0x7f)<<8 | uint16(input[headerLength+3]&0x3f)
+	if endCode != 0 {
+		if message, ok := endCodeMessage[endCode]; ok {
+			return nil, EndCodeError{Code: int(endCode), Message: fmt.Sprintf("%s(%.4X)", message, endCode)}
+		}
+		return nil, EndCodeError{Code: int(endCode), Message: fmt.Sprintf("%s(%.4X)", "unknown error", endCode)}
+	}
+	return input[headerLength+4:], nil
+}
+
+// EndCodeError the plc rejected the request with the end code
+type EndCodeError struct {
+	Code    int
+	Message string
+}
+
+func (e EndCodeError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,154 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func TestFinsTcpShakeHands(t *testing.T) {
+	f := NewFinsTcp()
+	want := decodeHex(t, "46494E53 0000000C 00000000 00000000 00000000")
+	if got := f.ShakeHands(); !bytes.Equal(got, want) {
+		t.Fatalf("ShakeHands() = % X, want % X", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{"assigned", "46494E53 00000010 00000001 00000000 000000EF 00000001", false},
+		{"rejected", "46494E53 00000010 00000001 00000021 000000EF 00000001", true},
+		{"wrong command", "46494E53 00000010 00000002 00000000 000000EF 00000001", true},
+		{"short", "46494E53 00000010 00000001 00000000", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewFinsTcp().ParseShakeHands(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseShakeHands() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFinsTcpReadWrite(t *testing.T) {
+	f := NewFinsTcp()
+	if err := f.ParseShakeHands(decodeHex(t, "46494E53 00000010 00000001 00000000 000000EF 00000001")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  func() []byte
+		want string
+	}{
+		{
+			name: "read DM100 word",
+			got:  func() []byte { return f.ReadVar(false, &DataMemory, 100, 0, 1) },
+			want: "46494E53 0000001A 00000002 00000000 80 00 02 00 01 00 00 EF 00 01 0101 82 0064 00 0001",
+		},
+		{
+			name: "read CIO10.05 bit",
+			got:  func() []byte { return f.ReadVar(true, &CoreIO, 10, 5, 1) },
+			want: "46494E53 0000001A 00000002 00000000 80 00 02 00 01 00 00 EF 00 02 0101 30 000A 05 0001",
+		},
+		{
+			name: "read LR0 mapped to CIO1000",
+			got:  func() []byte { return f.ReadVar(false, &LinkRelay, 0, 0, 2) },
+			want: "46494E53 0000001A 00000002 00000000 80 00 02 00 01 00 00 EF 00 03 0101 B0 03E8 00 0002",
+		},
+		{
+			name: "write DM200 two words",
+			got:  func() []byte { return f.WriteVar(false, &DataMemory, 200, 0, 2, []byte{0x12, 0x34, 0x56, 0x78}) },
+			want: "46494E53 0000001E 00000002 00000000 80 00 02 00 01 00 00 EF 00 04 0102 82 00C8 00 0002 12345678",
+		},
+		{
+			name: "read EM bank 2",
+			got:  func() []byte { return f.ReadVar(false, NewExtendedMemory(2), 16, 0, 1) },
+			want: "46494E53 0000001A 00000002 00000000 80 00 02 00 01 00 00 EF 00 05 0101 A2 0010 00 0001",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := tt.got(), decodeHex(t, tt.want); !bytes.Equal(got, want) {
+				t.Fatalf("got % X, want % X", got, want)
+			}
+		})
+	}
+}
+
+func TestFinsTcpParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantCode int
+		wantErr  bool
+	}{
+		{
+			name:     "data",
+			response: "46494E53 00000018 00000002 00000000 C0 00 02 00 EF 00 00 01 00 01 0101 0000 1234",
+			want:     "1234",
+		},
+		{
+			name:     "address range error",
+			response: "46494E53 00000016 00000002 00000000 C0 00 02 00 EF 00 00 01 00 01 0101 1103",
+			wantCode: 0x1103,
+			wantErr:  true,
+		},
+		{
+			name:     "non-fatal plc error bits are ignored",
+			response: "46494E53 00000018 00000002 00000000 C0 00 02 00 EF 00 00 01 00 01 0101 0040 ABCD",
+			want:     "ABCD",
+		},
+		{
+			name:     "relay error bit is ignored",
+			response: "46494E53 00000018 00000002 00000000 C0 00 02 00 EF 00 00 01 00 01 0101 8000 5678",
+			want:     "5678",
+		},
+		{
+			name:     "not a response",
+			response: "46494E53 00000018 00000002 00000000 80 00 02 00 EF 00 00 01 00 01 0101 0000 1234",
+			wantErr:  true,
+		},
+		{
+			name:     "incomplete",
+			response: "46494E53 00000018 00000002 00000000 C0 00 02 00 EF 00 00 01 00 01 0101 0000",
+			wantErr:  true,
+		},
+		{
+			name:     "fins/tcp error code",
+			response: "46494E53 00000008 00000002 00000003",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFinsTcp().Parse(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCode != 0 {
+				var endCodeErr EndCodeError
+				if !errors.As(err, &endCodeErr) || endCodeErr.Code != tt.wantCode {
+					t.Fatalf("Parse() error = %v, want end code %.4X", err, tt.wantCode)
+				}
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Parse() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	hostLinkStart      = '@'
+	hostLinkTerminator = "*\r"
+	hostLinkFinsHeader = "FA"
+
+	hostLinkShortFinsHeaderLength = 4 // ICF DA2 SA2 SID
+)
+
+var (
+	cModeEndCodeMessage = map[string]string{
+		"01": "Not executable in RUN mode",
+		"02": "Not executable in MONITOR mode",
+		"03": "UM write-protected",
+		"04": "Address over",
+		"0B": "Not executable in PROGRAM mode",
+		"13": "FCS error",
+		"14": "Format error",
+		"15": "Entry number data error",
+		"16": "Command not supported",
+		"18": "Frame length error",
+		"19": "Not executable",
+		"21": "Not executable due to CPU Unit CPU error",
+		"23": "User memory protected",
+		"A3": "Aborted due to FCS error in transmission data",
+		"A4": "Aborted due to format error in transmission data",
+		"A5": "Aborted due to entry number data error in transmission data",
+		"A8": "Aborted due to frame length error in transmission data",
+	}
+)
+
+func (h *HostLinkCMode) SetUnitNumber(unit int) {
+	h.unitNumber = unit
+}
+
+// ReadVar bit access is emulated by reading the whole word, timer/counter flags are read by RG
+func (h *HostLinkCMode) ReadVar(isBit bool, area *AreaCode, address int, bitAddress int, length int) []byte {
+	if area.cModeCode == "" {
+		return nil
+	}
+	h.bitAddress = -1
+	if isBit && area.cModeCode != TimerFlag.cModeCode {
+		h.bitAddress = bitAddress
+		length = 1
+	}
+	text := fmt.Sprintf("%s%.4d%.4d", cModeBank(area), address, length)
+	return hostLinkFrame(h.unitNumber, "R"+area.cModeCode, text)
+}
+
+// WriteVar only word access is possible, a single bit must be written with the whole word
+func (h *HostLinkCMode) WriteVar(isBit bool, area *AreaCode, address int, bitAddress int, length int, data []byte) []byte {
+	if isBit || area.cModeCode == "" || area.cModeCode == TimerFlag.cModeCode {
+		return nil
+	}
+	text := fmt.Sprintf("%s%.4d%X", cModeBank(area), address, data)
+	return hostLinkFrame(h.unitNumber, "W"+area.cModeCode, text)
+}
+
+func (h *HostLinkCMode) Parse(input []byte) ([]byte, error) {
+	headerCode, text, err := parseHostLinkFrame(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(text) < 2 {
+		return nil, fmt.Errorf("invalid length of text: %d", len(text))
+	}
+	endCode := string(text[0:2])
+	if endCode != "00" {
+		return nil, hostLinkEndCodeError(endCode)
+	}
+	data := text[2:]
+	switch {
+	case strings.HasPrefix(headerCode, "W"):
+		return nil, nil
+	case headerCode == "R"+TimerFlag.cModeCode:
+		// the status of every timer/counter is a single character
+		if len(data) == 0 {
+			return nil, fmt.Errorf("no any data")
+		}
+		if data[0] == '0' {
+			return []byte{0}, nil
+		}
+		return []byte{1}, nil
+	}
+	result, err := hex.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if h.bitAddress >= 0 {
+		if len(result) < 2 {
+			return nil, fmt.Errorf("invalid length of data: %d", len(result))
+		}
+		word := binary.BigEndian.Uint16(result[0:2])
+		return []byte{byte(word>>h.bitAddress) & 0x01}, nil
+	}
+	return result, nil
+}
+
+func (h *HostLinkFins) SetUnitNumber(unit int) {
+	h.unitNumber = unit
+}
+
+func (h *HostLinkFins) ReadVar(isBit bool, area *AreaCode, address int, bitAddress int, length int) []byte {
+	command := make([]byte, 2)
+	binary.BigEndian.PutUint16(command, uint16(memoryAreaRead))
+	result := append(command, memoryAreaParam(isBit, area, address, bitAddress, length)...)
+	return h.frame(result)
+}
+
+func (h *HostLinkFins) WriteVar(isBit bool, area *AreaCode, address int, bitAddress int, length int, data []byte) []byte {
+	command := make([]byte, 2)
+	binary.BigEndian.PutUint16(command, uint16(memoryAreaWrite))
+	result := append(command, memoryAreaParam(isBit, area, address, bitAddress, length)...)
+	result = append(result, data...)
+	return h.frame(result)
+}
+
+func (h *HostLinkFins) Parse(input []byte) ([]byte, error) {
+	headerCode, text, err := parseHostLinkFrame(input)
+	if err != nil {
+		return nil, err
+	}
+	if headerCode != hostLinkFinsHeader {
+		return nil, fmt.Errorf("unexpected header code: %s", headerCode)
+	}
+	if len(text) < 2 {
+		return nil, fmt.Errorf("invalid length of text: %d", len(text))
+	}
+	if endCode := string(text[0:2]); endCode != "00" {
+		return nil, hostLinkEndCodeError(endCode)
+	}
+	fins, err := hex.DecodeString(string(text[2:]))
+	if err != nil {
+		return nil, err
+	}
+	headerLength := hostLinkShortFinsHeaderLength
+	if h.isNetwork {
+		headerLength = finsHeaderLength
+	}
+	return parseFinsResponse(fins, headerLength)
+}
+
+// frame response wait time(1) + fins header + fins command, all in hexadecimal characters
+func (h *HostLinkFins) frame(command []byte) []byte {
+	h.header.sid++
+	hd := h.header
+	fins := []byte{hd.icf, hd.da2, hd.sa2, hd.sid}
+	if h.isNetwork {
+		fins = []byte{hd.icf, hd.rsv, hd.gct, hd.dna, hd.da1, hd.da2, hd.sna, hd.sa1, hd.sa2, hd.sid}
+	}
+	fins = append(fins, command...)
+	return hostLinkFrame(h.unitNumber, hostLinkFinsHeader, fmt.Sprintf("0%X", fins))
+}
+
+// cModeBank EM commands carry the bank number in front of the address, two spaces mean the current bank
+func cModeBank(area *AreaCode) string {
+	if area.cModeCode != ExtendedMemory.cModeCode {
+		return ""
+	}
+	if area.wordCode >= 0xA0 && area.wordCode <= 0xAC {
+		return fmt.Sprintf("%.2X", area.wordCode-0xA0)
+	}
+	return "  "
+}
+
+// hostLinkFrame @ + unit number(2) + header code(2) + text + FCS(2) + * + CR
+func hostLinkFrame(unit int, headerCode string, text string) []byte {
+	body := fmt.Sprintf("%c%.2d%s%s", hostLinkStart, unit, headerCode, text)
+	return []byte(fmt.Sprintf("%s%.2X%s", body, hostLinkFcs([]byte(body)), hostLinkTerminator))
+}
+
+// parseHostLinkFrame verifies the FCS of a response and returns the header code and the text
+func parseHostLinkFrame(input []byte) (string, []byte, error) {
+	start := bytes.IndexByte(input, hostLinkStart)
+	end := bytes.LastIndex(input, []byte(hostLinkTerminator))
+	if start < 0 || end < 0 || end-start < 7 {
+		return "", nil, fmt.Errorf("incomplete host link frame: % X", input)
+	}
+	body := input[start : end-2]
+	fcs := fmt.Sprintf("%.2X", hostLinkFcs(body))
+	if fcs != string(input[end-2:end]) {
+		return "", nil, fmt.Errorf("fcs is %s not match %s which received", fcs, input[end-2:end])
+	}
+	return string(body[3:5]), body[5:], nil
+}
+
+// hostLinkFcs exclusive or of all characters from @ to the end of the text
+func hostLinkFcs(input []byte) byte {
+	fcs := byte(0)
+	for _, temp := range input {
+		fcs ^= temp
+	}
+	return fcs
+}
+
+// hostLinkEndCodeError the end codes of host link are two hex digits
+func hostLinkEndCodeError(endCode string) error {
+	code, _ := strconv.ParseUint(endCode, 16, 8)
+	if message, ok := cModeEndCodeMessage[endCode]; ok {
+		return EndCodeError{Code: int(code), Message: fmt.Sprintf("%s(%s)", message, endCode)}
+	}
+	return EndCodeError{Code: int(code), Message: fmt.Sprintf("%s(%s)", "unknown error", endCode)}
+}
@@ -0,0 +1,125 @@
+package protocolStack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHostLinkCMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		isBit    bool
+		area     *AreaCode
+		address  int
+		bit      int
+		length   int
+		request  string
+		response string
+		want     []byte
+		wantErr  bool
+	}{
+		{
+			name: "read DM0", area: &DataMemory, length: 1,
+			request: "@00RD0000000157*\r", response: "@00RD00123452*\r", want: []byte{0x12, 0x34},
+		},
+		{
+			name: "read DM0 bit 4", isBit: true, area: &DataMemory, bit: 4, length: 1,
+			request: "@00RD0000000157*\r", response: "@00RD00001057*\r", want: []byte{1},
+		},
+		{
+			name: "fcs error", area: &DataMemory, length: 1,
+			request: "@00RD0000000157*\r", response: "@00RD00123453*\r", wantErr: true,
+		},
+		{
+			name: "end code", area: &DataMemory, length: 1,
+			request: "@00RD0000000157*\r", response: "@00RD1552*\r", wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHostLinkCMode()
+			if got := h.ReadVar(tt.isBit, tt.area, tt.address, tt.bit, tt.length); string(got) != tt.request {
+				t.Fatalf("ReadVar() = %q, want %q", got, tt.request)
+			}
+			got, err := h.Parse([]byte(tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Fatalf("Parse() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+
+	h := NewHostLinkCMode()
+	if got, want := string(h.WriteVar(false, &DataMemory, 0, 0, 1, []byte{0x12, 0x34})), "@00WD0000123457*\r"; got != want {
+		t.Fatalf("WriteVar() = %q, want %q", got, want)
+	}
+	if _, err := h.Parse([]byte("@00WD0053*\r")); err != nil {
+		t.Fatalf("Parse() of the write response error = %v", err)
+	}
+	if got := h.WriteVar(true, &DataMemory, 0, 0, 1, []byte{0x00, 0x01}); got != nil {
+		t.Fatalf("WriteVar() of a bit = %q, want nil", got)
+	}
+}
+
+func TestHostLinkFins(t *testing.T) {
+	h := NewHostLinkFins(false)
+	want := "@00FA00000000101018200640000017F*\r"
+	if got := string(h.ReadVar(false, &DataMemory, 100, 0, 1)); got != want {
+		t.Fatalf("ReadVar() = %q, want %q", got, want)
+	}
+	got, err := h.Parse([]byte("@00FA004000000101010000123446*\r"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !bytes.Equal(got, []byte{0x12, 0x34}) {
+		t.Fatalf("Parse() = % X, want 12 34", got)
+	}
+}
+
+func TestHostLinkFcs(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		want  byte
+	}{
+		{"read DM0", "@00RD00000001", 0x57},
+		{"write DM0", "@00WD00001234", 0x57},
+		{"empty", "", 0x00},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostLinkFcs([]byte(tt.frame)); got != tt.want {
+				t.Fatalf("hostLinkFcs(%q) = %.2X, want %.2X", tt.frame, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHostLinkFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantCode string
+		wantText string
+		wantErr  bool
+	}{
+		{"response", "@00RD00123452*\r", "RD", "001234", false},
+		{"noise before the frame", "\x00@00RD00123452*\r", "RD", "001234", false},
+		{"fcs error", "@00RD00123453*\r", "", "", true},
+		{"missing terminator", "@00RD00123452", "", "", true},
+		{"too short", "@0052*\r", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, text, err := parseHostLinkFrame([]byte(tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHostLinkFrame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (code != tt.wantCode || string(text) != tt.wantText) {
+				t.Fatalf("parseHostLinkFrame() = %s %q, want %s %q", code, text, tt.wantCode, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package protocolStack
+
+type commandType uint16
+type EndCode map[uint16]string
+
+// AreaCode FINS memory area designation, bitCode is used for bit access and wordCode for word access,
+// cModeCode is the area letter of the Host Link C-mode header code, empty when C-mode cannot access the area
+type AreaCode struct {
+	bitCode   byte
+	wordCode  byte
+	offset    int // added to the FINS word address, e.g. LR0 is mapped to CIO1000 on CS/CJ series
+	cModeCode string
+}
+
+var (
+	CoreIO         = AreaCode{0x30, 0xB0, 0, "R"}
+	LinkRelay      = AreaCode{0x30, 0xB0, 1000, "L"}
+	WorkArea       = AreaCode{0x31, 0xB1, 0, ""}
+	HoldingRelay   = AreaCode{0x32, 0xB2, 0, "H"}
+	AuxiliaryRelay = AreaCode{0x33, 0xB3, 0, "J"}
+	DataMemory     = AreaCode{0x02, 0x82, 0, "D"}
+	TimerFlag      = AreaCode{0x09, 0x00, 0, "G"}      //定时器完成标志
+	CounterFlag    = AreaCode{0x09, 0x00, 0x8000, "G"} //计数器完成标志
+	TimerValue     = AreaCode{0x00, 0x89, 0, "C"}      //定时器当前值
+	CounterValue   = AreaCode{0x00, 0x89, 0x8000, "C"} //计数器当前值
+	ExtendedMemory = AreaCode{0x0A, 0x98, 0, "E"}      //当前EM区块
+)
+
+const (
+	memoryAreaRead  commandType = 0x0101
+	memoryAreaWrite commandType = 0x0102
+)
+
+var (
+	endCodeMessage = EndCode{
+		0x0001: "Service canceled",
+		0x0101: "Local node not in network",
+		0x0102: "Token timeout",
+		0x0103: "Retries failed",
+		0x0104: "Too many send frames",
+		0x0105: "Node address range error",
+		0x0106: "Node address duplication",
+		0x0201: "Destination node not in network",
+		0x0202: "Unit missing",
+		0x0203: "Third node missing",
+		0x0204: "Destination node busy",
+		0x0205: "Response timeout",
+		0x0301: "Communications controller error",
+		0x0302: "CPU unit error",
+		0x0401: "Undefined command",
+		0x0402: "Not supported by model/version",
+		0x0501: "Destination address setting error",
+		0x0502: "No routing tables",
+		0x1001: "Command too long",
+		0x1002: "Command too short",
+		0x1003: "Elements/data don't match",
+		0x1004: "Command format error",
+		0x1101: "No area type",
+		0x1103: "Address range error",
+		0x1104: "Address range exceeded",
+		0x110B: "Response too long",
+		0x1201: "Mode error",
+		0x2002: "Protected",
+		0x2101: "Read-only",
+		0x2201: "Not executable in current mode",
+		0x2203: "Operating mode error",
+		0x2301: "File device missing",
+		0x2302: "Memory missing",
+		0x2502: "Memory error",
+		0x2601: "No protection",
+		0x2606: "Service already executing",
+	}
+)
+
+type finsHeader struct {
+	icf byte // information control field
+	rsv byte // reserved
+	gct byte // gateway count
+	dna byte // destination network address
+	da1 byte // destination node address
+	da2 byte // destination unit address
+	sna byte // source network address
+	sa1 byte // source node address
+	sa2 byte // source unit address
+	sid byte // service id
+}
+
+type FinsTcp struct {
+	header     finsHeader
+	clientNode byte
+	serverNode byte
+}
+
+// HostLinkCMode Host Link C-mode commands, only word access is supported by the protocol
+type HostLinkCMode struct {
+	unitNumber int
+	bitAddress int // bit extracted from the read word by Parse, -1 for word access
+}
+
+// HostLinkFins FINS commands embedded in Host Link frames,
+// the full FINS header is sent when isNetwork is set, otherwise the CPU of the unit is addressed directly
+type HostLinkFins struct {
+	unitNumber int
+	isNetwork  bool
+	header     finsHeader
+}
+
+type Fins interface {
+	ReadVar(isBit bool, area *AreaCode, address int, bitAddress int, length int) []byte
+	WriteVar(isBit bool, area *AreaCode, address int, bitAddress int, length int, data []byte) []byte
+	Parse(input []byte) ([]byte, error)
+}
+
+// HostLink serial protocols addressing several plc on the same bus by unit number
+type HostLink interface {
+	Fins
+	SetUnitNumber(unit int)
+}
+
+// NewExtendedMemory returns the area code of a specified EM bank, bank 0 to 12 are supported
+func NewExtendedMemory(bank int) *AreaCode {
+	if bank < 0 || bank > 0x0C {
+		return &ExtendedMemory
+	}
+	return &AreaCode{bitCode: 0x20 + byte(bank), wordCode: 0xA0 + byte(bank), cModeCode: "E"}
+}
+
+func NewFinsTcp() *FinsTcp {
+	f := FinsTcp{header: finsHeader{
+		icf: 0x80, rsv: 0x00, gct: 0x02,
+		dna: 0x00, da1: 0x00, da2: 0x00,
+		sna: 0x00, sa1: 0x00, sa2: 0x00,
+		sid: 0x00,
+	}}
+	return &f
+}
+
+func NewHostLinkCMode() HostLink {
+	h := HostLinkCMode{bitAddress: -1}
+	return &h
+}
+
+func NewHostLinkFins(isNetwork bool) HostLink {
+	h := HostLinkFins{isNetwork: isNetwork, header: finsHeader{
+		icf: 0x80, rsv: 0x00, gct: 0x02,
+		dna: 0x00, da1: 0x00, da2: 0x00,
+		sna: 0x00, sa1: 0x00, sa2: 0x00,
+		sid: 0x00,
+	}}
+	if !isNetwork {
+		h.header.icf = 0x00
+	}
+	return &h
+}
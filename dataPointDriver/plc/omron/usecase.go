@@ -0,0 +1,356 @@
+package omron
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataPointDriver/plc/omron/protocolStack"
+	"didaGatewayCenter/dataTransform"
+	"didaGatewayCenter/domain"
+	"didaGatewayCenter/net"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+type omron struct {
+	f           protocolStack.Fins
+	isConnected bool
+	iLogU       domain.ILogUsecase
+	iDTU        domain.IDataTransformUsecase
+	conn        domain.Software
+	portConfig  *domain.DataPointPortConfig
+	monitor     connection.Monitor
+	policy      connection.Policy
+	lock        sync.Mutex
+}
+
+func (o *omron) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
+	o.portConfig = portConfig
+	o.iDTU = transform
+	o.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	o.monitor.SetMaxTimeouts(o.policy.MaxTimeouts)
+
+	go func() {
+		o.connect()
+	}()
+
+	return
+}
+
+func (o *omron) connect() {
+	address := fmt.Sprintf("%s:%d", o.portConfig.Param.IP, o.portConfig.Param.PortNumber)
+	o.iLogU.GetLogger().Info("omron plc is connecting", zap.String("portName", o.portConfig.PortName))
+	if o.portConfig.PortType == domain.SerialType {
+		switch o.portConfig.DeviceType {
+		case domain.DeviceTypeHostLinkCMode:
+			o.f = protocolStack.NewHostLinkCMode()
+		case domain.DeviceTypeHostLinkFins1:
+			o.f = protocolStack.NewHostLinkFins(false)
+		case domain.DeviceTypeHostLinkFins2:
+			o.f = protocolStack.NewHostLinkFins(true)
+		default:
+			o.iLogU.GetLogger().Error("unsupported omron serial device type", zap.String("portName", o.portConfig.PortName),
+				zap.Int("deviceType", int(o.portConfig.DeviceType)))
+			return
+		}
+		for {
+			if o.isConnected {
+				time.Sleep(time.Second)
+				continue
+			}
+			// the port is reopened after too many timeouts in a row
+			o.lock.Lock()
+			net.Close(o.conn)
+			o.lock.Unlock()
+			port := connection.OpenSerial(o.portConfig, time.Millisecond*50, o.policy, o.iLogU.GetLogger())
+			o.lock.Lock()
+			o.conn = net.New(port)
+			o.lock.Unlock()
+			o.isConnected = true
+			o.monitor.Connected()
+		}
+	}
+	logger := o.iLogU.GetLogger()
+	backoff := connection.NewBackoff(o.policy)
+	for {
+		if o.isConnected {
+			time.Sleep(time.Second)
+			continue
+		}
+		o.lock.Lock()
+		net.Close(o.conn)
+		o.lock.Unlock()
+		tcpConn, err := net.Dial("tcp", address, o.policy.DialTimeout)
+		if err != nil {
+			backoff.Wait(logger, "cannot connect to omron plc", err, zap.String("name", o.portConfig.PortName), zap.String("address", address))
+			continue
+		}
+		// the plc assigns the fins node addresses on every new tcp connection
+		finsTcp := protocolStack.NewFinsTcp()
+		r, err := tcpConn.WriteReadTimeout(finsTcp.ShakeHands(), time.Second)
+		if err != nil {
+			net.Close(tcpConn)
+			backoff.Wait(logger, "send fins node address request failed", err, zap.String("name", o.portConfig.PortName), zap.String("address", address))
+			continue
+		}
+		if err := finsTcp.ParseShakeHands(r); err != nil {
+			net.Close(tcpConn)
+			backoff.Wait(logger, "fins node address request rejected", err, zap.String("name", o.portConfig.PortName), zap.String("address", address))
+			continue
+		}
+		o.iLogU.GetLogger().Info("omron plc connected", zap.String("name", o.portConfig.PortName), zap.String("address", address),
+			zap.Int("attempts", backoff.Attempts()+1))
+		backoff.Reset()
+		o.lock.Lock()
+		o.f = finsTcp
+		o.conn = tcpConn
+		o.lock.Unlock()
+		o.isConnected = true
+		o.monitor.Connected()
+	}
+}
+
+func (o *omron) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
+	if !o.isConnected {
+		time.Sleep(time.Second)
+		return nil, connection.ErrNotConnected
+	}
+	regAddr := variableList.Param.RegAddr
+	bitAddress := variableList.Param.BitAddr
+	area, isBit := getArea(variableList)
+	if area == nil {
+		o.iLogU.GetLogger().Warn("unsupported omron register type", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Int("regType", int(variableList.Param.RegType)))
+		return nil, connection.InvalidAddress("unsupported omron register type: %d", variableList.Param.RegType)
+	}
+	length := getLength(variableList.DataType)
+	if isBit {
+		length = 1
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.setUnitNumber(deviceInfo)
+	bb := o.f.ReadVar(isBit, area, regAddr, bitAddress, length)
+	if len(bb) == 0 {
+		o.iLogU.GetLogger().Warn("register type is not supported by the protocol", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Int("regType", int(variableList.Param.RegType)))
+		return nil, connection.InvalidAddress("register type %d is not supported by the protocol", variableList.Param.RegType)
+	}
+	o.monitor.Begin()
+	r, err := o.conn.WriteReadTimeout(bb, connection.RespTimeout(portInfo.Param))
+	if err != nil {
+		return nil, o.checkError(portInfo, deviceInfo, variableList, err)
+	}
+
+	valueByte, err := o.f.Parse(r)
+	if err != nil {
+		o.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, parseError(err)
+	}
+	o.monitor.Succeeded()
+	if isBit {
+		// bit access returns one byte per bit, convert it like a bool variable
+		if len(valueByte) != 1 {
+			o.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name),
+				zap.String("result", fmt.Sprintf("% X", valueByte)))
+			return nil, connection.InvalidResponse(fmt.Errorf("invalid length of bit: %d", len(valueByte)))
+		}
+		valueByte = []byte{0, valueByte[0]}
+		tempVariable := *variableList
+		tempVariable.DataType = domain.VarDataTypeBool
+		variableList = &tempVariable
+	}
+	value, err := o.iDTU.ByteToValue(deviceInfo, variableList, valueByte)
+	if err != nil {
+		o.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
+	}
+	return value, nil
+}
+
+func (o *omron) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
+	if !o.isConnected {
+		return connection.ErrNotConnected
+	}
+	dataType := variableInfo.DataType
+	regAddr := variableInfo.Param.RegAddr
+	bitAddress := variableInfo.Param.BitAddr
+	area, isBit := getArea(variableInfo)
+	if area == nil {
+		return connection.InvalidAddress("unsupported omron register type: %d", variableInfo.Param.RegType)
+	}
+	length := getLength(dataType)
+
+	var data []byte
+	if isBit || dataType == domain.VarDataTypeBit {
+		// a single bit of a word is written directly instead of read-modify-write
+		isBit = true
+		length = 1
+		data = []byte{0x00}
+		if dataTransform.NewValue(inputValue).ToBool() {
+			data = []byte{0x01}
+		}
+	} else {
+		result, err := o.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue)
+		if err != nil {
+			return connection.InvalidAddress("%v", err)
+		}
+		if len(result) == 1 {
+			result = []byte{0, result[0]}
+		}
+		data = result
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.setUnitNumber(deviceInfo)
+	timeout := connection.RespTimeout(portInfo.Param)
+	o.monitor.Begin()
+	if isBit && portInfo.DeviceType == domain.DeviceTypeHostLinkCMode {
+		// C-mode cannot write a single bit, the word containing the bit is read and written back
+		switch variableInfo.Param.RegType {
+		case domain.RegTypeOmronTSRegister, domain.RegTypeOmronCSRegister:
+			return connection.InvalidAddress("register type %d is not writable by the protocol", variableInfo.Param.RegType)
+		}
+		r, err := o.conn.WriteReadTimeout(o.f.ReadVar(false, area, regAddr, 0, 1), timeout)
+		if err != nil {
+			return o.checkError(portInfo, deviceInfo, variableInfo, err)
+		}
+		word, err := o.f.Parse(r)
+		if err != nil {
+			return parseError(err)
+		}
+		if len(word) != 2 {
+			return connection.InvalidResponse(fmt.Errorf("invalid length of word: %d", len(word)))
+		}
+		v := binary.BigEndian.Uint16(word)
+		if data[0] != 0 {
+			v |= 1 << bitAddress
+		} else {
+			v &^= 1 << bitAddress
+		}
+		isBit = false
+		data = make([]byte, 2)
+		binary.BigEndian.PutUint16(data, v)
+	}
+	r1 := o.f.WriteVar(isBit, area, regAddr, bitAddress, length, data)
+	if len(r1) == 0 {
+		return connection.InvalidAddress("register type %d is not writable by the protocol", variableInfo.Param.RegType)
+	}
+	r, err := o.conn.WriteReadTimeout(r1, timeout)
+	if err != nil {
+		return o.checkError(portInfo, deviceInfo, variableInfo, err)
+	}
+	if _, err := o.f.Parse(r); err != nil {
+		o.iLogU.GetLogger().Warn("omron write failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name),
+			zap.String("result", fmt.Sprintf("% X", r)), zap.Error(err))
+		return parseError(err)
+	}
+	o.monitor.Succeeded()
+	return nil
+}
+
+// Health the health of the connection of the port
+func (o *omron) Health() domain.PortHealth {
+	return o.monitor.Health()
+}
+
+// Transmit sends a raw frame on the serial bus between the requests of the cycle sample
+func (o *omron) Transmit(request []byte, timeout time.Duration) ([]byte, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if !o.isConnected {
+		return nil, connection.ErrNotConnected
+	}
+	return o.conn.WriteReadTimeout(request, timeout)
+}
+
+// checkError classifies and logs the error of a request, the connection or the serial port is reopened when it is lost or
+// times out too often
+func (o *omron) checkError(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, err error) error {
+	driverErr := connection.Classify(err)
+	if o.monitor.Failed(driverErr) {
+		o.isConnected = false
+		o.monitor.Disconnected()
+		o.iLogU.GetLogger().Warn("omron connection lost, reconnecting", zap.String("portName", portInfo.PortName), zap.Error(driverErr))
+		return driverErr
+	}
+	o.iLogU.GetLogger().Warn("omron request failed", zap.String("portName", portInfo.PortName),
+		zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(driverErr))
+	return driverErr
+}
+
+// parseError the end codes of a response are exceptions of the plc
+func parseError(err error) error {
+	var endCodeErr protocolStack.EndCodeError
+	if errors.As(err, &endCodeErr) {
+		return connection.Exception(endCodeErr.Code, err)
+	}
+	return connection.InvalidResponse(err)
+}
+
+// setUnitNumber serial host link addresses the plc on the bus by the device address
+func (o *omron) setUnitNumber(deviceInfo *domain.DeviceList) {
+	if h, ok := o.f.(protocolStack.HostLink); ok {
+		h.SetUnitNumber(deviceInfo.DevAddr)
+	}
+}
+
+func NewOmronDriver(iLogU domain.ILogUsecase) domain.IDataPointDriverUsecase {
+	o := omron{
+		iLogU: iLogU,
+	}
+	return &o
+}
+
+// getArea returns the fins area of the variable and whether it must be accessed by bit
+func getArea(variableInfo *domain.DataPointVariableList) (area *protocolStack.AreaCode, isBit bool) {
+	isBit = variableInfo.DataType == domain.VarDataTypeBool
+	switch variableInfo.Param.RegType {
+	case domain.RegTypeOmronCIORegister:
+		area = &protocolStack.CoreIO
+	case domain.RegTypeOmronLRegister:
+		area = &protocolStack.LinkRelay
+	case domain.RegTypeOmronWARegister:
+		area = &protocolStack.WorkArea
+	case domain.RegTypeOmronHRegister:
+		area = &protocolStack.HoldingRelay
+	case domain.RegTypeOmronARegister:
+		area = &protocolStack.AuxiliaryRelay
+	case domain.RegTypeOmronDMRegister:
+		area = &protocolStack.DataMemory
+	case domain.RegTypeOmronEMRegister:
+		area = protocolStack.NewExtendedMemory(variableInfo.Param.DBNum)
+	case domain.RegTypeOmronTSRegister:
+		area = &protocolStack.TimerFlag
+		isBit = true
+	case domain.RegTypeOmronCSRegister:
+		area = &protocolStack.CounterFlag
+		isBit = true
+	case domain.RegTypeOmronTVRegister:
+		area = &protocolStack.TimerValue
+		isBit = false
+	case domain.RegTypeOmronCVRegister:
+		area = &protocolStack.CounterValue
+		isBit = false
+	}
+	return
+}
+
+// getLength returns the number of words of the data type
+func getLength(dataType domain.DataType) int {
+	switch dataType {
+	case domain.VarDataTypeUint32, domain.VarDataTypeInt32, domain.VarDataTypeFloat:
+		return 2
+	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
+		return 4
+	}
+	return 1
+}
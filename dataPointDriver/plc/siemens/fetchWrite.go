@@ -0,0 +1,251 @@
+package siemens
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataPointDriver/plc/siemens/protocolStack"
+	"didaGatewayCenter/domain"
+	"didaGatewayCenter/net"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+type fetchWrite struct {
+	f           *protocolStack.FetchWrite
+	isConnected bool
+	iLogU       domain.ILogUsecase
+	iDTU        domain.IDataTransformUsecase
+	fetchConn   domain.Software
+	writeConn   domain.Software
+	portConfig  *domain.DataPointPortConfig
+	monitor     connection.Monitor
+	policy      connection.Policy
+	lock        sync.Mutex
+}
+
+func (s *fetchWrite) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
+	s.portConfig = portConfig
+	s.iDTU = transform
+	s.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	s.monitor.SetMaxTimeouts(s.policy.MaxTimeouts)
+
+	go func() {
+		s.connect()
+	}()
+
+	return
+}
+
+// connect the fetch and the write connection are passive connections of their own on the plc
+func (s *fetchWrite) connect() {
+	param := s.portConfig.Param
+	writePort := param.WritePortNumber
+	if writePort == 0 {
+		writePort = param.PortNumber + 1
+	}
+	fetchAddress := fmt.Sprintf("%s:%d", param.IP, param.PortNumber)
+	writeAddress := fmt.Sprintf("%s:%d", param.IP, writePort)
+	s.iLogU.GetLogger().Info("siemens fetch/write is connecting", zap.String("portName", s.portConfig.PortName))
+	logger := s.iLogU.GetLogger()
+	backoff := connection.NewBackoff(s.policy)
+	for {
+		if s.isConnected {
+			time.Sleep(time.Second)
+			continue
+		}
+		s.lock.Lock()
+		net.Close(s.fetchConn)
+		net.Close(s.writeConn)
+		s.lock.Unlock()
+		fetchConn, err := net.Dial("tcp", fetchAddress, s.policy.DialTimeout)
+		if err != nil {
+			backoff.Wait(logger, "cannot connect to the fetch port", err, zap.String("name", s.portConfig.PortName), zap.String("address", fetchAddress))
+			continue
+		}
+		writeConn, err := net.Dial("tcp", writeAddress, s.policy.DialTimeout)
+		if err != nil {
+			net.Close(fetchConn)
+			backoff.Wait(logger, "cannot connect to the write port", err, zap.String("name", s.portConfig.PortName), zap.String("address", writeAddress))
+			continue
+		}
+		s.iLogU.GetLogger().Info("siemens fetch/write connected", zap.String("name", s.portConfig.PortName),
+			zap.String("fetchAddress", fetchAddress), zap.String("writeAddress", writeAddress), zap.Int("attempts", backoff.Attempts()+1))
+		backoff.Reset()
+		s.lock.Lock()
+		s.fetchConn = fetchConn
+		s.writeConn = writeConn
+		s.lock.Unlock()
+		s.isConnected = true
+		s.monitor.Connected()
+	}
+}
+
+func (s *fetchWrite) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
+	if !s.isConnected {
+		time.Sleep(time.Second)
+		return nil, connection.ErrNotConnected
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	org, err := getOrgId(variableList.Param.RegType)
+	if err != nil {
+		s.iLogU.GetLogger().Warn("invalid variable config", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidAddress("%v", err)
+	}
+	dataType := variableList.DataType
+	s.monitor.Begin()
+	valueByte, err := s.fetch(org, getDBNum(variableList.Param.RegType, variableList.Param.DBNum), variableList.Param.RegAddr, getByteLength(dataType))
+	if err != nil {
+		return nil, s.checkError(portInfo, deviceInfo, variableList, err)
+	}
+	s.monitor.Succeeded()
+	switch dataType {
+	case domain.VarDataTypeBool, domain.VarDataTypeBit:
+		valueByte = []byte{0, valueByte[0] >> variableList.Param.BitAddr & 0x01}
+	case domain.VarDataTypeByte:
+		valueByte = []byte{0, valueByte[0]}
+	}
+	value, err := s.iDTU.ByteToValue(deviceInfo, variableList, valueByte)
+	if err != nil {
+		s.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
+	}
+	return value, nil
+}
+
+func (s *fetchWrite) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
+	if !s.isConnected {
+		return connection.ErrNotConnected
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	org, err := getOrgId(variableInfo.Param.RegType)
+	if err != nil {
+		return connection.InvalidAddress("%v", err)
+	}
+	dbNum := getDBNum(variableInfo.Param.RegType, variableInfo.Param.DBNum)
+	regAddr := variableInfo.Param.RegAddr
+	result, err := s.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue)
+	if err != nil {
+		return connection.InvalidAddress("%v", err)
+	}
+	s.monitor.Begin()
+	switch variableInfo.DataType {
+	case domain.VarDataTypeBool, domain.VarDataTypeBit:
+		// a single bit cannot be written, the byte holding it is read first
+		current, err := s.fetch(org, dbNum, regAddr, 1)
+		if err != nil {
+			return s.checkError(portInfo, deviceInfo, variableInfo, err)
+		}
+		mask := byte(1) << variableInfo.Param.BitAddr
+		if result[1] != 0 {
+			result = []byte{current[0] | mask}
+		} else {
+			result = []byte{current[0] &^ mask}
+		}
+	}
+	if err := s.write(org, dbNum, regAddr, result); err != nil {
+		return s.checkError(portInfo, deviceInfo, variableInfo, err)
+	}
+	s.monitor.Succeeded()
+	return nil
+}
+
+// Health the health of the connection of the port
+func (s *fetchWrite) Health() domain.PortHealth {
+	return s.monitor.Health()
+}
+
+func (s *fetchWrite) fetch(org protocolStack.OrgId, dbNum int, address int, length int) ([]byte, error) {
+	r, err := s.fetchConn.WriteReadTimeout(s.f.Fetch(org, dbNum, address, length), connection.RespTimeout(s.portConfig.Param))
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.f.Parse(r)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return data, nil
+}
+
+// write data blocks are written in words, the neighbouring byte of an odd address or length is read first
+func (s *fetchWrite) write(org protocolStack.OrgId, dbNum int, address int, data []byte) error {
+	if org == protocolStack.OrgIdDB && (address%2 != 0 || len(data)%2 != 0) {
+		start := address - address%2
+		length := (address+len(data)+1)/2*2 - start
+		current, err := s.fetch(org, dbNum, start, length)
+		if err != nil {
+			return err
+		}
+		aligned := make([]byte, length)
+		copy(aligned, current)
+		copy(aligned[address-start:], data)
+		address, data = start, aligned
+	}
+	bb, err := s.f.Write(org, dbNum, address, data)
+	if err != nil {
+		return connection.InvalidAddress("%v", err)
+	}
+	r, err := s.writeConn.WriteReadTimeout(bb, connection.RespTimeout(s.portConfig.Param))
+	if err != nil {
+		return err
+	}
+	if _, err = s.f.Parse(r); err != nil {
+		return parseError(err)
+	}
+	return nil
+}
+
+// checkError classifies and logs the error of a request, both connections are reopened when one is lost or times out
+// too often
+func (s *fetchWrite) checkError(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, err error) error {
+	driverErr := connection.Classify(err)
+	if s.monitor.Failed(driverErr) {
+		s.isConnected = false
+		s.monitor.Disconnected()
+		s.iLogU.GetLogger().Warn("fetch/write connection lost, reconnecting", zap.String("portName", portInfo.PortName), zap.Error(driverErr))
+		return driverErr
+	}
+	s.iLogU.GetLogger().Warn("fetch/write request failed", zap.String("portName", portInfo.PortName),
+		zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(driverErr))
+	return driverErr
+}
+
+func NewSiemensFetchWriteDriver(iLogU domain.ILogUsecase) domain.IDataPointDriverUsecase {
+	s := fetchWrite{
+		iLogU: iLogU,
+		f:     protocolStack.NewFetchWrite(),
+	}
+	return &s
+}
+
+func getOrgId(regType domain.RegisterType) (protocolStack.OrgId, error) {
+	switch regType {
+	case domain.RegTypeSiemensDB:
+		return protocolStack.OrgIdDB, nil
+	case domain.RegTypeSiemensM:
+		return protocolStack.OrgIdFlags, nil
+	case domain.RegTypeSiemensI:
+		return protocolStack.OrgIdInputs, nil
+	case domain.RegTypeSiemensQ:
+		return protocolStack.OrgIdOutputs, nil
+	}
+	return 0, fmt.Errorf("register type %d is not supported by fetch/write", regType)
+}
+
+func getByteLength(dataType domain.DataType) int {
+	switch dataType {
+	case domain.VarDataTypeUint16, domain.VarDataTypeInt16:
+		return 2
+	case domain.VarDataTypeUint32, domain.VarDataTypeInt32, domain.VarDataTypeFloat:
+		return 4
+	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
+		return 8
+	}
+	return 1
+}
@@ -0,0 +1,191 @@
+package siemens
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataPointDriver/plc/siemens/protocolStack"
+	"didaGatewayCenter/domain"
+	"didaGatewayCenter/net"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	ppiMasterAddress = 0 // address of the gateway on the PPI bus
+	ppiPollTimes     = 5 // polls while the plc keeps answering that the response is not ready
+)
+
+type ppi struct {
+	p           *protocolStack.Ppi
+	isConnected bool
+	iLogU       domain.ILogUsecase
+	iDTU        domain.IDataTransformUsecase
+	conn        domain.Software
+	portConfig  *domain.DataPointPortConfig
+	monitor     connection.Monitor
+	policy      connection.Policy
+	lock        sync.Mutex
+}
+
+func (s *ppi) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
+	s.portConfig = portConfig
+	s.iDTU = transform
+	s.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	s.monitor.SetMaxTimeouts(s.policy.MaxTimeouts)
+
+	go func() {
+		s.connect()
+	}()
+
+	return
+}
+
+func (s *ppi) connect() {
+	s.iLogU.GetLogger().Info("siemens ppi is connecting", zap.String("portName", s.portConfig.PortName))
+	for {
+		if s.isConnected {
+			time.Sleep(time.Second)
+			continue
+		}
+		// the port is reopened after too many timeouts in a row
+		s.lock.Lock()
+		net.Close(s.conn)
+		s.lock.Unlock()
+		port := connection.OpenSerial(s.portConfig, time.Millisecond*50, s.policy, s.iLogU.GetLogger())
+		s.lock.Lock()
+		s.conn = net.New(port)
+		s.lock.Unlock()
+		s.isConnected = true
+		s.monitor.Connected()
+	}
+}
+
+func (s *ppi) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
+	if !s.isConnected {
+		time.Sleep(time.Second)
+		return nil, connection.ErrNotConnected
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sizeType, sizeCount := getTransportSize(variableList)
+	area, dbNum := getPpiArea(variableList.Param.RegType)
+	bb := s.p.SetStation(byte(deviceInfo.DevAddr)).ReadVar(sizeType, sizeCount, dbNum, area, variableList.Param.RegAddr, variableList.Param.BitAddr)
+	valueByte, err := s.request(portInfo, bb)
+	if err != nil {
+		driverErr := s.checkError(err)
+		s.iLogU.GetLogger().Warn("read from siemens ppi failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(driverErr))
+		return nil, driverErr
+	}
+	s.monitor.Succeeded()
+	if len(valueByte) == 1 && variableList.DataType != domain.VarDataTypeString {
+		valueByte = append([]byte{0}, valueByte[0])
+	}
+	value, err := s.iDTU.ByteToValue(deviceInfo, variableList, valueByte)
+	if err != nil {
+		s.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
+	}
+	return value, nil
+}
+
+func (s *ppi) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
+	if !s.isConnected {
+		return connection.ErrNotConnected
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dataType := variableInfo.DataType
+	sizeType, sizeCount := getTransportSize(variableInfo)
+	area, dbNum := getPpiArea(variableInfo.Param.RegType)
+	result, err := s.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue)
+	if err != nil {
+		return connection.InvalidAddress("%v", err)
+	}
+	if dataType == domain.VarDataTypeBit {
+		result = []byte{result[1]}
+	}
+	bb := s.p.SetStation(byte(deviceInfo.DevAddr)).WriteVar(sizeType, sizeCount, dbNum, area, variableInfo.Param.RegAddr, variableInfo.Param.BitAddr, result)
+	if _, err := s.request(portInfo, bb); err != nil {
+		driverErr := s.checkError(err)
+		s.iLogU.GetLogger().Warn("siemens ppi write failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(driverErr))
+		return driverErr
+	}
+	s.monitor.Succeeded()
+	return nil
+}
+
+// checkError classifies the error of a request, the serial port is reopened when the plc times out too often
+func (s *ppi) checkError(err error) error {
+	driverErr := connection.Classify(err)
+	if s.monitor.Failed(driverErr) {
+		s.isConnected = false
+		s.monitor.Disconnected()
+		s.iLogU.GetLogger().Warn("siemens ppi not answering, reopening the serial port", zap.String("portName", s.portConfig.PortName),
+			zap.Error(driverErr))
+	}
+	return driverErr
+}
+
+// request sends the request, waits for the short acknowledge and polls the plc until the response is ready
+func (s *ppi) request(portInfo *domain.DataPointPortConfig, bb []byte) ([]byte, error) {
+	timeout := connection.RespTimeout(portInfo.Param)
+	s.monitor.Begin()
+	r, err := s.conn.WriteReadTimeout(bb, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.p.ParseAck(r); err != nil {
+		return nil, connection.InvalidResponse(err)
+	}
+	for i := 0; i < ppiPollTimes; i++ {
+		r, err = s.conn.WriteReadTimeout(s.p.Poll(), timeout)
+		if err != nil {
+			return nil, err
+		}
+		if !s.p.IsAck(r) {
+			data, err := s.p.Parse(r)
+			if err != nil {
+				return nil, parseError(err)
+			}
+			return data, nil
+		}
+	}
+	return nil, &domain.DriverError{Kind: domain.DriverErrorTimeout, Err: fmt.Errorf("no response after polling %d times", ppiPollTimes)}
+}
+
+// Health the health of the connection of the port
+func (s *ppi) Health() domain.PortHealth {
+	return s.monitor.Health()
+}
+
+// Transmit sends a raw frame on the serial bus between the requests of the cycle sample
+func (s *ppi) Transmit(request []byte, timeout time.Duration) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.isConnected {
+		return nil, connection.ErrNotConnected
+	}
+	return s.conn.WriteReadTimeout(request, timeout)
+}
+
+// getPpiArea V memory of S7-200 is addressed as DB1 on PPI
+func getPpiArea(regType domain.RegisterType) (protocolStack.Area, int) {
+	if regType == domain.RegTypeSiemensV {
+		return protocolStack.AreaTypeDB, 1
+	}
+	return getArea(regType, true), 0
+}
+
+func NewSiemensPPIDriver(iLogU domain.ILogUsecase) domain.IDataPointDriverUsecase {
+	s := ppi{
+		iLogU: iLogU,
+		p:     protocolStack.NewPpi(ppiMasterAddress),
+	}
+	return &s
+}
@@ -0,0 +1,121 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type OrgId byte
+type fetchWriteOpCode byte
+
+const (
+	fetchWriteHeaderLength = 16
+
+	fetchWriteWriteRequest fetchWriteOpCode = 0x03
+	fetchWriteWriteAck     fetchWriteOpCode = 0x04
+	fetchWriteFetchRequest fetchWriteOpCode = 0x05
+	fetchWriteFetchAck     fetchWriteOpCode = 0x06
+)
+
+const (
+	OrgIdDB      OrgId = 0x01
+	OrgIdFlags   OrgId = 0x02
+	OrgIdInputs  OrgId = 0x03
+	OrgIdOutputs OrgId = 0x04
+)
+
+var fetchWriteMagic = []byte{'S', '5'}
+
+var fetchWriteErrorCode = ReturnCode{
+	0x02: "Requested block does not exist",
+	0x03: "Requested block is too small",
+	0x04: "Start address is too high",
+}
+
+// FetchWrite legacy RFC1006 fetch/write of S5 and the CP343/443, the plc listens on passive connections,
+// one for fetch and another for write.
+// Data blocks are addressed in words and the other areas in bytes, byte addresses are converted by the requests
+type FetchWrite struct {
+	offset int // bytes in front of the requested data when a data block is read from an odd address
+	length int // requested bytes
+}
+
+func NewFetchWrite() *FetchWrite {
+	f := FetchWrite{}
+	return &f
+}
+
+// Fetch builds the request reading length bytes from the byte address
+func (f *FetchWrite) Fetch(org OrgId, dbNum int, address int, length int) []byte {
+	f.offset = 0
+	f.length = length
+	start, count := address, length
+	if org == OrgIdDB {
+		f.offset = address % 2
+		start = address / 2
+		count = (f.offset + length + 1) / 2
+	}
+	return fetchWriteHeader(fetchWriteFetchRequest, org, dbNum, start, count)
+}
+
+// Write builds the request writing data to the byte address, data blocks can only be written from even addresses
+// with a whole number of words
+func (f *FetchWrite) Write(org OrgId, dbNum int, address int, data []byte) ([]byte, error) {
+	start, count := address, len(data)
+	if org == OrgIdDB {
+		if address%2 != 0 || len(data)%2 != 0 {
+			return nil, fmt.Errorf("data block must be written in words, address %d length %d", address, len(data))
+		}
+		start = address / 2
+		count = len(data) / 2
+	}
+	return append(fetchWriteHeader(fetchWriteWriteRequest, org, dbNum, start, count), data...), nil
+}
+
+// Parse checks the acknowledge of a fetch or write request, the requested bytes are returned for fetch
+func (f *FetchWrite) Parse(input []byte) ([]byte, error) {
+	if len(input) < fetchWriteHeaderLength {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	if !bytes.Equal(input[0:2], fetchWriteMagic) || input[2] != fetchWriteHeaderLength {
+		return nil, fmt.Errorf("invalid header: % X", input[0:fetchWriteHeaderLength])
+	}
+	if errorCode := input[8]; errorCode != 0 {
+		if message, ok := fetchWriteErrorCode[errorCode]; ok {
+			return nil, ReturnCodeError{Code: errorCode, Message: message}
+		}
+		return nil, ReturnCodeError{Code: errorCode, Message: "unknown error"}
+	}
+	switch fetchWriteOpCode(input[5]) {
+	case fetchWriteWriteAck:
+		return nil, nil
+	case fetchWriteFetchAck:
+		data := input[fetchWriteHeaderLength:]
+		if len(data) < f.offset+f.length {
+			return nil, fmt.Errorf("incomplete data,require %d but got %d", f.offset+f.length, len(data))
+		}
+		return data[f.offset : f.offset+f.length], nil
+	}
+	return nil, fmt.Errorf("unexpected op code: %X", input[5])
+}
+
+// fetchWriteHeader system id(2) + header length + id op code + length op code + op code +
+// org block(id, length, org id, db number, start address(2), length(2)) + empty block(id, length)
+func fetchWriteHeader(opCode fetchWriteOpCode, org OrgId, dbNum int, start int, count int) []byte {
+	result := make([]byte, fetchWriteHeaderLength)
+	copy(result, fetchWriteMagic)
+	result[2] = fetchWriteHeaderLength
+	result[3] = 0x01
+	result[4] = 0x03
+	result[5] = byte(opCode)
+	result[6] = 0x03
+	result[7] = 0x08
+	result[8] = byte(org)
+	result[9] = byte(dbNum)
+	binary.BigEndian.PutUint16(result[10:12], uint16(start))
+	binary.BigEndian.PutUint16(result[12:14], uint16(count))
+	result[14] = 0xFF
+	result[15] = 0x02
+	return result
+}
@@ -0,0 +1,106 @@
+package protocolStack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFetchWriteFetch(t *testing.T) {
+	tests := []struct {
+		name     string
+		org      OrgId
+		dbNum    int
+		address  int
+		length   int
+		request  string
+		response string
+		want     string
+		wantCode byte
+		wantErr  bool
+	}{
+		{
+			name: "data block from an even address", org: OrgIdDB, dbNum: 10, address: 4, length: 4,
+			request:  "5335 10 01 03 05 03 08 01 0A 0002 0002 FF02",
+			response: "5335 10 01 03 06 0F 03 00 FF 07 00 00 00 00 00 11223344",
+			want:     "11223344",
+		},
+		{
+			name: "data block from an odd address", org: OrgIdDB, dbNum: 10, address: 5, length: 2,
+			request:  "5335 10 01 03 05 03 08 01 0A 0002 0002 FF02",
+			response: "5335 10 01 03 06 0F 03 00 FF 07 00 00 00 00 00 11223344",
+			want:     "2233",
+		},
+		{
+			name: "flags in bytes", org: OrgIdFlags, address: 10, length: 3,
+			request:  "5335 10 01 03 05 03 08 02 00 000A 0003 FF02",
+			response: "5335 10 01 03 06 0F 03 00 FF 07 00 00 00 00 00 AABBCC",
+			want:     "AABBCC",
+		},
+		{
+			name: "block does not exist", org: OrgIdDB, dbNum: 99, length: 2,
+			request:  "5335 10 01 03 05 03 08 01 63 0000 0001 FF02",
+			response: "5335 10 01 03 06 0F 03 02 FF 07 00 00 00 00 00",
+			wantCode: 0x02, wantErr: true,
+		},
+		{
+			name: "incomplete data", org: OrgIdDB, dbNum: 10, length: 4,
+			request:  "5335 10 01 03 05 03 08 01 0A 0000 0002 FF02",
+			response: "5335 10 01 03 06 0F 03 00 FF 07 00 00 00 00 00 1122",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFetchWrite()
+			if got, want := f.Fetch(tt.org, tt.dbNum, tt.address, tt.length), decodeHex(t, tt.request); !bytes.Equal(got, want) {
+				t.Fatalf("Fetch() = % X, want % X", got, want)
+			}
+			got, err := f.Parse(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCode != 0 {
+				var returnCodeErr ReturnCodeError
+				if !errors.As(err, &returnCodeErr) || returnCodeErr.Code != tt.wantCode {
+					t.Fatalf("Parse() error = %v, want error code %X", err, tt.wantCode)
+				}
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Parse() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchWriteWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		org     OrgId
+		dbNum   int
+		address int
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"data block", OrgIdDB, 10, 4, "1234", "5335 10 01 03 03 03 08 01 0A 0002 0001 FF02 1234", false},
+		{"outputs", OrgIdOutputs, 0, 1, "01", "5335 10 01 03 03 03 08 04 00 0001 0001 FF02 01", false},
+		{"data block from an odd address", OrgIdDB, 10, 5, "1234", "", true},
+		{"data block with an odd length", OrgIdDB, 10, 4, "12", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFetchWrite().Write(tt.org, tt.dbNum, tt.address, decodeHex(t, tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Write() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+	got, err := NewFetchWrite().Parse(decodeHex(t, "5335 10 01 03 04 0F 03 00 FF 07 00 00 00 00 00"))
+	if err != nil || got != nil {
+		t.Fatalf("Parse() of the write acknowledge = % X, %v", got, err)
+	}
+}
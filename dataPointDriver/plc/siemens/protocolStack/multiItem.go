@@ -0,0 +1,251 @@
+package protocolStack
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// minPduLength every plc supports, used until setup communication is confirmed
+	minPduLength = 240
+	// maxItemCount items of a single read or write request
+	maxItemCount = 20
+
+	s7RequestHeaderLength  = 10
+	s7ResponseHeaderLength = 12
+	s7ItemParameterLength  = 12
+	s7ItemDataHeaderLength = 4
+)
+
+// Item one variable of a multi-item request, Data is only used by write requests
+type Item struct {
+	Size       TransportSize
+	Count      int
+	DBNum      int
+	Area       Area
+	Address    int
+	BitAddress int
+	Data       []byte
+}
+
+// ItemResult the data of an item of a read response, or the reason the plc rejected the item
+type ItemResult struct {
+	Data []byte
+	Err  error
+}
+
+// PduLength negotiated pdu length
+func (s *S7Comm) PduLength() int {
+	return s.pduLength
+}
+
+// ParseCommunication reads the pdu length the plc confirmed in the response to setup communication
+func (s *S7Comm) ParseCommunication(i []byte) error {
+	pdu, err := s.ackPDU(i)
+	if err != nil {
+		return err
+	}
+	parameter, _, err := splitPDU(pdu)
+	if err != nil {
+		return err
+	}
+	if len(parameter) < 8 || FunctionCode(parameter[0]) != FuncCodeSetupCommunication {
+		return fmt.Errorf("invalid setup communication response: % X", parameter)
+	}
+	if pduLength := int(binary.BigEndian.Uint16(parameter[6:8])); pduLength >= minPduLength {
+		s.pduLength = pduLength
+	}
+	return nil
+}
+
+// SplitRead groups the items into read requests fitting in the negotiated pdu, the request and the response both
+// count, every group holds the indexes of the items, the items which do not fit in the pdu alone are oversized
+func (s *S7Comm) SplitRead(items []Item) (groups [][]int, oversized []int) {
+	return s.split(items, func(requestLength int, responseLength int, singleItem Item) (int, int) {
+		return requestLength + s7ItemParameterLength, responseLength + s7ItemDataHeaderLength + evenLength(itemDataLength(singleItem))
+	})
+}
+
+// SplitWrite groups the items into write requests fitting in the negotiated pdu, like SplitRead
+func (s *S7Comm) SplitWrite(items []Item) (groups [][]int, oversized []int) {
+	return s.split(items, func(requestLength int, responseLength int, singleItem Item) (int, int) {
+		return requestLength + s7ItemParameterLength + s7ItemDataHeaderLength + evenLength(len(singleItem.Data)), responseLength + 1
+	})
+}
+
+func (s *S7Comm) split(items []Item, add func(requestLength int, responseLength int, singleItem Item) (int, int)) ([][]int, []int) {
+	var (
+		groups    [][]int
+		current   []int
+		oversized []int
+	)
+	emptyRequest, emptyResponse := s7RequestHeaderLength+2, s7ResponseHeaderLength+2
+	requestLength, responseLength := emptyRequest, emptyResponse
+	for index, singleItem := range items {
+		if aloneRequest, aloneResponse := add(emptyRequest, emptyResponse, singleItem); aloneRequest > s.pduLength || aloneResponse > s.pduLength {
+			oversized = append(oversized, index)
+			continue
+		}
+		newRequest, newResponse := add(requestLength, responseLength, singleItem)
+		if len(current) > 0 && (len(current) == maxItemCount || newRequest > s.pduLength || newResponse > s.pduLength) {
+			groups = append(groups, current)
+			current = nil
+			newRequest, newResponse = add(emptyRequest, emptyResponse, singleItem)
+		}
+		current = append(current, index)
+		requestLength, responseLength = newRequest, newResponse
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups, oversized
+}
+
+// ReadVars builds a read request of several items, the items must fit in the pdu, see SplitRead
+func (s *S7Comm) ReadVars(items []Item) []byte {
+	return s.frame(s.readVarsPDU(items))
+}
+
+// WriteVars builds a write request of several items, the items must fit in the pdu, see SplitWrite
+func (s *S7Comm) WriteVars(items []Item) []byte {
+	return s.frame(s.writeVarsPDU(items))
+}
+
+// ParseReadVars returns the result of every item of a read response in the order of the request
+func (s *S7Comm) ParseReadVars(i []byte) ([]ItemResult, error) {
+	pdu, err := s.ackPDU(i)
+	if err != nil {
+		return nil, err
+	}
+	parameter, data, err := splitPDU(pdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(parameter) < 2 {
+		return nil, fmt.Errorf("invalid parameter length: %d", len(parameter))
+	}
+	results := make([]ItemResult, parameter[1])
+	for index := range results {
+		if len(data) < 1 {
+			return nil, fmt.Errorf("missing data of item %d", index)
+		}
+		if returnCode := data[0]; returnCode != 0xff {
+			results[index].Err = returnCodeError(returnCode)
+			// a failed item carries the return code and the transport size only
+			data = skip(data, s7ItemDataHeaderLength)
+			continue
+		}
+		if len(data) < s7ItemDataHeaderLength {
+			return nil, fmt.Errorf("incomplete data of item %d", index)
+		}
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		switch TransportSizeInData(data[1]) {
+		case DataTransportSizeBBit, DataTransportSizeByte, DataTransportSizeBInt:
+			length = (length + 7) / 8
+		}
+		if len(data) < s7ItemDataHeaderLength+length {
+			return nil, fmt.Errorf("incomplete data of item %d", index)
+		}
+		results[index].Data = data[s7ItemDataHeaderLength : s7ItemDataHeaderLength+length]
+		data = skip(data, s7ItemDataHeaderLength+evenLength(length))
+	}
+	return results, nil
+}
+
+// ParseWriteVars returns the error of every item of a write response, nil for the items written
+func (s *S7Comm) ParseWriteVars(i []byte) ([]error, error) {
+	pdu, err := s.ackPDU(i)
+	if err != nil {
+		return nil, err
+	}
+	parameter, data, err := splitPDU(pdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(parameter) < 2 || len(data) < int(parameter[1]) {
+		return nil, fmt.Errorf("incomplete write response: % X", pdu)
+	}
+	results := make([]error, parameter[1])
+	for index := range results {
+		if data[index] != 0xff {
+			results[index] = returnCodeError(data[index])
+		}
+	}
+	return results, nil
+}
+
+// ackPDU checks TPKT and COTP and returns the s7comm pdu
+func (s *S7Comm) ackPDU(i []byte) ([]byte, error) {
+	if len(i) < TPKTLength+1 {
+		return nil, fmt.Errorf("invalid length of bytes")
+	}
+	length := binary.BigEndian.Uint16(i[2:4])
+	if len(i) != int(length) {
+		return nil, fmt.Errorf("incomplete data,require %d but got %d", length, len(i))
+	}
+	cotpLength := int(i[4])
+	if len(i) < TPKTLength+cotpLength+1 {
+		return nil, fmt.Errorf("invalid length of bytes")
+	}
+	return i[TPKTLength+cotpLength+1:], nil
+}
+
+// splitPDU checks the header of an ack data pdu and returns the parameters and the data
+func splitPDU(pdu []byte) ([]byte, []byte, error) {
+	if len(pdu) < s7ResponseHeaderLength {
+		return nil, nil, fmt.Errorf("invalid length of s7comm pdu: %d", len(pdu))
+	}
+	parameterLength := int(binary.BigEndian.Uint16(pdu[6:8]))
+	dataLength := int(binary.BigEndian.Uint16(pdu[8:10]))
+	if len(pdu) < s7ResponseHeaderLength+parameterLength+dataLength {
+		return nil, nil, fmt.Errorf("incomplete s7comm pdu: %d", len(pdu))
+	}
+	if errorClass, errorCode := pdu[10], pdu[11]; errorClass != 0 || errorCode != 0 {
+		return nil, nil, fmt.Errorf("error class %X error code %X", errorClass, errorCode)
+	}
+	parameter := pdu[s7ResponseHeaderLength : s7ResponseHeaderLength+parameterLength]
+	data := pdu[s7ResponseHeaderLength+parameterLength : s7ResponseHeaderLength+parameterLength+dataLength]
+	return parameter, data, nil
+}
+
+// ReturnCodeError the plc rejected the request or an item with the return code
+type ReturnCodeError struct {
+	Code    byte
+	Message string
+}
+
+func (e ReturnCodeError) Error() string {
+	return fmt.Sprintf("%s(%X)", e.Message, e.Code)
+}
+
+func returnCodeError(returnCode byte) error {
+	if returnDataString, ok := returnDataCode[returnCode]; ok {
+		return ReturnCodeError{Code: returnCode, Message: returnDataString}
+	}
+	return ReturnCodeError{Code: returnCode, Message: "unkown error"}
+}
+
+// itemDataLength bytes of the data the plc returns for the item
+func itemDataLength(singleItem Item) int {
+	switch singleItem.Size {
+	case TransportSizeBit, TransportSizeByte, Char:
+		return singleItem.Count
+	case Word, Int, Date, S5Time, Counter, Timer:
+		return singleItem.Count * 2
+	case Dt:
+		return singleItem.Count * 8
+	}
+	return singleItem.Count * 4
+}
+
+// skip the last item is not padded
+func skip(data []byte, length int) []byte {
+	if len(data) < length {
+		return nil
+	}
+	return data[length:]
+}
+
+func evenLength(length int) int {
+	return length + length%2
+}
@@ -0,0 +1,216 @@
+package protocolStack
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommunication(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     int
+	}{
+		{"480", "0300001B 02F080 3203 0000 0000 0008 0000 0000 F000 0001 0001 01E0", 480},
+		{"smaller than the minimum", "0300001B 02F080 3203 0000 0000 0008 0000 0000 F000 0001 0001 00C8", minPduLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewS7Comm(0, 1)
+			if err := s.ParseCommunication(decodeHex(t, tt.response)); err != nil {
+				t.Fatalf("ParseCommunication() error = %v", err)
+			}
+			if got := s.PduLength(); got != tt.want {
+				t.Fatalf("PduLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadVars(t *testing.T) {
+	items := []Item{
+		{Size: Real, Count: 1, DBNum: 1, Area: AreaTypeDB, Address: 0},
+		{Size: TransportSizeByte, Count: 1, Area: AreaTypeFLAGS, Address: 10},
+	}
+	got := clearPduRef(NewS7Comm(0, 1).ReadVars(items), s7PduRefOffset)
+	want := decodeHex(t, "0300002B 02F080 3201 0000 0000 001A 0000 0402 120A10 08 0001 0001 84 000000 120A10 02 0001 0000 83 000050")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("ReadVars() = % X, want % X", got, want)
+	}
+}
+
+func TestParseReadVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []string
+		wantCode []byte
+		wantErr  bool
+	}{
+		{
+			name:     "real and byte",
+			response: "03000022 02F080 3203 0000 0001 0002 000D 0000 0402 FF07 0004 41200000 FF04 0008 2A",
+			want:     []string{"41200000", "2A"},
+			wantCode: []byte{0, 0},
+		},
+		{
+			name:     "odd item is padded",
+			response: "03000021 02F080 3203 0000 0001 0002 000C 0000 0402 FF04 0008 2A 00 FF04 0010 1234",
+			want:     []string{"2A", "1234"},
+			wantCode: []byte{0, 0},
+		},
+		{
+			name:     "failed item",
+			response: "0300001E 02F080 3203 0000 0001 0002 0009 0000 0402 0A00 0000 FF04 0008 2A",
+			want:     []string{"", "2A"},
+			wantCode: []byte{0x0A, 0},
+		},
+		{
+			name:     "missing item",
+			response: "0300001A 02F080 3203 0000 0001 0002 0005 0000 0402 FF04 0008 2A",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewS7Comm(0, 1).ParseReadVars(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReadVars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("ParseReadVars() returned %d results, want %d", len(results), len(tt.want))
+			}
+			for index, result := range results {
+				if code := tt.wantCode[index]; code != 0 {
+					var returnCodeErr ReturnCodeError
+					if !errors.As(result.Err, &returnCodeErr) || returnCodeErr.Code != code {
+						t.Fatalf("item %d error = %v, want return code %X", index, result.Err, code)
+					}
+					continue
+				}
+				if result.Err != nil || !bytes.Equal(result.Data, decodeHex(t, tt.want[index])) {
+					t.Fatalf("item %d = % X, %v, want %s", index, result.Data, result.Err, tt.want[index])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteVars(t *testing.T) {
+	items := []Item{
+		{Size: TransportSizeByte, Count: 1, DBNum: 1, Area: AreaTypeDB, Address: 0, Data: []byte{0x01}},
+		{Size: Word, Count: 1, DBNum: 1, Area: AreaTypeDB, Address: 2, Data: []byte{0x12, 0x34}},
+	}
+	got := clearPduRef(NewS7Comm(0, 1).WriteVars(items), s7PduRefOffset)
+	want := decodeHex(t, "03000037 02F080 3201 0000 0000 001A 000C 0502 120A10 02 0001 0001 84 000000 120A10 04 0001 0001 84 000010"+
+		"00 05 0008 01 00 00 05 0010 1234")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("WriteVars() = % X, want % X", got, want)
+	}
+
+	results, err := NewS7Comm(0, 1).ParseWriteVars(decodeHex(t, "03000017 02F080 3203 0000 0001 0002 0002 0000 0502 FF 05"))
+	if err != nil {
+		t.Fatalf("ParseWriteVars() error = %v", err)
+	}
+	var returnCodeErr ReturnCodeError
+	if len(results) != 2 || results[0] != nil || !errors.As(results[1], &returnCodeErr) || returnCodeErr.Code != 0x05 {
+		t.Fatalf("ParseWriteVars() = %v, want [nil, invalid address]", results)
+	}
+}
+
+func bytesItems(lengths ...int) []Item {
+	items := make([]Item, 0, len(lengths))
+	for index, length := range lengths {
+		items = append(items, Item{Size: TransportSizeByte, Count: length, DBNum: 1, Area: AreaTypeDB, Address: index * 1000})
+	}
+	return items
+}
+
+func repeat(length int, count int) []int {
+	lengths := make([]int, count)
+	for index := range lengths {
+		lengths[index] = length
+	}
+	return lengths
+}
+
+func TestSplitRead(t *testing.T) {
+	tests := []struct {
+		name          string
+		pduLength     int
+		items         []Item
+		want          [][]int
+		wantOversized []int
+	}{
+		{
+			name:      "all in one",
+			pduLength: minPduLength,
+			items:     bytesItems(2, 4, 1),
+			want:      [][]int{{0, 1, 2}},
+		},
+		{
+			name:      "request is full after 19 items",
+			pduLength: minPduLength,
+			items:     bytesItems(repeat(1, 25)...),
+			want:      [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18}, {19, 20, 21, 22, 23, 24}},
+		},
+		{
+			name:      "no more than 20 items in a larger pdu",
+			pduLength: 960,
+			items:     bytesItems(repeat(1, 21)...),
+			want:      [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, {20}},
+		},
+		{
+			name:      "response is full",
+			pduLength: minPduLength,
+			items:     bytesItems(100, 100, 100, 100, 100),
+			want:      [][]int{{0, 1}, {2, 3}, {4}},
+		},
+		{
+			name:      "response fits exactly",
+			pduLength: minPduLength,
+			// 14 + (4 + 200) + (4 + 18) = 240
+			items: bytesItems(200, 18, 1),
+			want:  [][]int{{0, 1}, {2}},
+		},
+		{
+			name:      "item larger than the pdu",
+			pduLength: minPduLength,
+			// 14 + (4 + 222) = 240 fits, 14 + (4 + 223) does not
+			items:         bytesItems(10, 223, 222, 300),
+			want:          [][]int{{0}, {2}},
+			wantOversized: []int{1, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewS7Comm(0, 1)
+			s.pduLength = tt.pduLength
+			got, oversized := s.SplitRead(tt.items)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("SplitRead() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(oversized, tt.wantOversized) {
+				t.Fatalf("SplitRead() oversized = %v, want %v", oversized, tt.wantOversized)
+			}
+		})
+	}
+}
+
+func TestSplitWrite(t *testing.T) {
+	items := bytesItems(100, 100, 10, 10)
+	for index := range items {
+		items[index].Data = make([]byte, items[index].Count)
+	}
+	s := NewS7Comm(0, 1)
+	// 12 + (12 + 4 + 100) = 128, a second item of 100 bytes exceeds 240
+	want := [][]int{{0}, {1, 2, 3}}
+	if got, oversized := s.SplitWrite(items); !reflect.DeepEqual(got, want) || oversized != nil {
+		t.Fatalf("SplitWrite() = %v, %v, want %v", got, oversized, want)
+	}
+}
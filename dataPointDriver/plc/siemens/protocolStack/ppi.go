@@ -0,0 +1,118 @@
+package protocolStack
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	ppiSD1 byte = 0x10 // fixed length frame without data
+	ppiSD2 byte = 0x68 // variable length frame
+	ppiED  byte = 0x16 // end delimiter
+	ppiSC  byte = 0xE5 // short acknowledge
+
+	ppiFCRequest  byte = 0x6C // send and request data, FCB and FCV set
+	ppiFCPoll     byte = 0x5C // request the response of the last request
+	ppiFCResponse byte = 0x08 // data response of the slave
+)
+
+// Ppi S7-200 point to point interface, the s7comm pdu is carried in SD2 frames without TPKT and COTP.
+// The gateway is the only master on the bus, so the token is never passed on
+type Ppi struct {
+	s             *S7Comm
+	masterAddress byte
+	station       byte
+}
+
+func NewPpi(masterAddress byte) *Ppi {
+	p := Ppi{s: NewS7Comm(0, 0), masterAddress: masterAddress}
+	return &p
+}
+
+// SetStation sets the address of the plc the following requests are sent to
+func (p *Ppi) SetStation(station byte) *Ppi {
+	p.station = station
+	return p
+}
+
+func (p *Ppi) ReadVar(size TransportSize, sizeCount int, dbNum int, area Area, address1 int, address2 int) []byte {
+	return p.frame(p.s.readVarPDU(size, sizeCount, dbNum, area, address1, address2))
+}
+
+func (p *Ppi) WriteVar(size TransportSize, sizeCount int, dbNum int, area Area, address1 int, address2 int, data []byte) []byte {
+	return p.frame(p.s.writeVarPDU(size, sizeCount, dbNum, area, address1, address2, data))
+}
+
+// Poll builds the SD1 frame fetching the response of the request acknowledged before
+func (p *Ppi) Poll() []byte {
+	body := []byte{p.station, p.masterAddress, ppiFCPoll}
+	result := append([]byte{ppiSD1}, body...)
+	return append(result, ppiFcs(body), ppiED)
+}
+
+// ParseAck checks the plc accepted the request with a short acknowledge
+func (p *Ppi) ParseAck(input []byte) error {
+	if len(input) == 0 {
+		return fmt.Errorf("no any data")
+	}
+	if input[0] != ppiSC {
+		return fmt.Errorf("request not acknowledged: % X", input)
+	}
+	return nil
+}
+
+// IsAck the plc answers a poll with a short acknowledge while the response is not ready yet
+func (p *Ppi) IsAck(input []byte) bool {
+	return len(input) == 1 && input[0] == ppiSC
+}
+
+// Parse checks the SD2 response frame and returns the data of the s7comm pdu in it
+func (p *Ppi) Parse(input []byte) ([]byte, error) {
+	start := bytes.IndexByte(input, ppiSD2)
+	if start < 0 {
+		return nil, fmt.Errorf("no frame start found: % X", input)
+	}
+	input = input[start:]
+	if len(input) < 9 {
+		return nil, fmt.Errorf("invalid length: %d", len(input))
+	}
+	length := int(input[1])
+	if input[2] != input[1] || input[3] != ppiSD2 {
+		return nil, fmt.Errorf("invalid frame header: % X", input[0:4])
+	}
+	if len(input) < length+6 {
+		return nil, fmt.Errorf("incomplete data,require %d but got %d", length+6, len(input))
+	}
+	body := input[4 : 4+length]
+	if fcs := ppiFcs(body); fcs != input[4+length] {
+		return nil, fmt.Errorf("fcs is %X not match %X which received", fcs, input[4+length])
+	}
+	if input[5+length] != ppiED {
+		return nil, fmt.Errorf("invalid frame end: %X", input[5+length])
+	}
+	if body[0] != p.masterAddress || body[1] != p.station {
+		return nil, fmt.Errorf("unexpected address, destination %d source %d", body[0], body[1])
+	}
+	if body[2] != ppiFCResponse {
+		return nil, fmt.Errorf("unexpected function code: %X", body[2])
+	}
+	return parsePDU(body[3:])
+}
+
+// frame SD2 LE LEr SD2 DA SA FC PDU FCS ED, LE counts DA to the end of the pdu
+func (p *Ppi) frame(pdu []byte) []byte {
+	body := []byte{p.station, p.masterAddress, ppiFCRequest}
+	body = append(body, pdu...)
+	result := []byte{ppiSD2, byte(len(body)), byte(len(body)), ppiSD2}
+	result = append(result, body...)
+	return append(result, ppiFcs(body), ppiED)
+}
+
+// ppiFcs arithmetic sum of DA to the end of the data, modulo 256
+func ppiFcs(input []byte) byte {
+	fcs := byte(0)
+	for _, temp := range input {
+		fcs += temp
+	}
+	return fcs
+}
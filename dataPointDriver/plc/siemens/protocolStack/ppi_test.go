@@ -0,0 +1,69 @@
+package protocolStack
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ppiPduRefOffset the pdu reference in a request, after SD2 LE LEr SD2 DA SA FC and the first 4 bytes of the
+// s7comm header
+const ppiPduRefOffset = 11
+
+func TestPpiReadVar(t *testing.T) {
+	p := NewPpi(0).SetStation(2)
+	got := p.ReadVar(TransportSizeByte, 1, 1, AreaTypeDB, 100, 0)
+	// the fcs covers the random pdu reference
+	fcsIndex := len(got) - 2
+	got[fcsIndex] -= got[ppiPduRefOffset] + got[ppiPduRefOffset+1]
+	got = clearPduRef(got, ppiPduRefOffset)
+	want := decodeHex(t, "68 1B 1B 68 02 00 6C 32 01 00 00 00 00 00 0E 00 00 04 01 12 0A 10 02 00 01 00 01 84 00 03 20 8B 16")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("ReadVar() = % X, want % X", got, want)
+	}
+}
+
+func TestPpiPoll(t *testing.T) {
+	want := decodeHex(t, "10 02 00 5C 5E 16")
+	if got := NewPpi(0).SetStation(2).Poll(); !bytes.Equal(got, want) {
+		t.Fatalf("Poll() = % X, want % X", got, want)
+	}
+}
+
+func TestPpiParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{"byte", "68 16 16 68 00 02 08 32 03 00 00 00 00 00 02 00 05 00 00 04 01 FF 04 00 08 2A 80 16", "2A", false},
+		{"fcs", "68 16 16 68 00 02 08 32 03 00 00 00 00 00 02 00 05 00 00 04 01 FF 04 00 08 2A 81 16", "", true},
+		{"other station", "68 16 16 68 00 03 08 32 03 00 00 00 00 00 02 00 05 00 00 04 01 FF 04 00 08 2A 81 16", "", true},
+		{"length mismatch", "68 16 17 68 00 02 08 32 03 00 00 00 00 00 02 00 05 00 00 04 01 FF 04 00 08 2A 80 16", "", true},
+		{"incomplete", "68 16 16 68 00 02 08 32 03 00 00 00 00 00 02 00 05 00 00 04 01 FF 04", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPpi(0).SetStation(2).Parse(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Parse() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPpiAck(t *testing.T) {
+	p := NewPpi(0)
+	if err := p.ParseAck([]byte{0xE5}); err != nil {
+		t.Fatalf("ParseAck() error = %v", err)
+	}
+	if err := p.ParseAck([]byte{0x10}); err == nil {
+		t.Fatal("ParseAck() accepted a frame which is no short acknowledge")
+	}
+	if !p.IsAck([]byte{0xE5}) || p.IsAck([]byte{0xE5, 0x16}) {
+		t.Fatal("IsAck() only matches the single short acknowledge")
+	}
+}
@@ -8,9 +8,9 @@ import (
 	"time"
 )
 
-const tPKTLength = 4
+const TPKTLength = 4
 
-type plcType string
+type PlcType string
 type MsgType byte
 type FunctionCode byte
 type Area byte
@@ -34,12 +34,12 @@ var (
 )
 
 const (
-	S1500     plcType = "S1500"
-	S1200     plcType = "S1200"
-	S400      plcType = "S400"
-	S300      plcType = "S300"
-	S200      plcType = "S200"
-	S200Smart plcType = "S200Smart"
+	S1500     PlcType = "S1500"
+	S1200     PlcType = "S1200"
+	S400      PlcType = "S400"
+	S300      PlcType = "S300"
+	S200      PlcType = "S200"
+	S200Smart PlcType = "S200Smart"
 )
 const (
 	MsgTypeJobRequest MsgType = 0x01
@@ -161,12 +161,20 @@ var (
 		{DstTSAP, 2, []byte{0x01, 0x00}}}
 	S200ParamMeter = []CotpParameter{{SrcTSAP, 2, []byte{'M', 'W'}},
 		{DstTSAP, 2, []byte{'M', 'W'}},
-		{TPDUSize, 1, []byte{0x00}}}
+		{TPDUSize, 1, []byte{0x0a}}}
 	S200SmartParamMeter = []CotpParameter{{SrcTSAP, 2, []byte{0x10, 0x00}},
 		{DstTSAP, 2, []byte{0x03, 0x00}},
-		{TPDUSize, 1, []byte{0x00}}}
+		{TPDUSize, 1, []byte{0x0a}}}
 )
 
+// default slot of the CPU when the slot is not configured
+var defaultSlot = map[PlcType]byte{
+	S1500: 1,
+	S1200: 1,
+	S400:  3,
+	S300:  2,
+}
+
 type s7CommHeader struct {
 	protocolId    byte
 	msgType       MsgType
@@ -211,39 +219,75 @@ type s7Data struct {
 	data          []byte
 }
 type S7Comm struct {
-	rack      byte // 机架号
-	slot      byte // 槽位号
+	rack      byte   // 机架号
+	slot      byte   // 槽位号
+	srcTSAP   uint16 // overrides the local TSAP of the plc family when not 0
+	dstTSAP   uint16 // overrides the remote TSAP computed from rack and slot when not 0
 	tpkt      *Tpkt
 	cotp      *CoTP
 	random    *rand.Rand
+	pduLength int // negotiated by setup communication, limits the items of a request
 	header    s7CommHeader
 	parameter s7CommParameter
 	data      s7Data
 }
 
 func NewS7Comm(rack byte, slot byte) *S7Comm {
-	s := S7Comm{rack: rack, slot: slot, tpkt: NewTPKT(), cotp: NewCoTPUsecase()}
+	s := S7Comm{rack: rack, slot: slot, tpkt: NewTPKT(), cotp: NewCoTPUsecase(), pduLength: minPduLength}
 	s.random = rand.New(rand.NewSource(time.Now().UnixNano()))
 	return &s
 }
-func (s *S7Comm) GetCoTPShakeHands(plcType2 plcType) []byte {
+
+// SetTSAP overrides the TSAPs of the connection request, 0 keeps the default of the plc family
+func (s *S7Comm) SetTSAP(srcTSAP uint16, dstTSAP uint16) *S7Comm {
+	s.srcTSAP = srcTSAP
+	s.dstTSAP = dstTSAP
+	return s
+}
+
+// GetDefaultSlot returns the slot the CPU of the plc family usually sits in
+func GetDefaultSlot(plcType2 PlcType) byte {
+	return defaultSlot[plcType2]
+}
+
+// GetCoTPShakeHands builds the connection request, the remote TSAP of S7-300/400/1200/1500 is
+// connection type(0x01 PG) followed by rack*0x20+slot
+func (s *S7Comm) GetCoTPShakeHands(plcType2 PlcType) []byte {
 	c := s.cotp
 	c.SetPduType(ConnectRequestCR)
+	var template []CotpParameter
 	switch plcType2 {
 	case S1500:
-		c.SetParameter(S1500ParamMeter)
+		template = S1500ParamMeter
 	case S1200:
-		c.SetParameter(S1200ParamMeter)
+		template = S1200ParamMeter
 	case S400:
-		c.SetParameter(S400ParamMeter)
+		template = S400ParamMeter
 	case S300:
-		c.SetParameter(S300ParamMeter)
+		template = S300ParamMeter
 	case S200:
-		c.SetParameter(S200ParamMeter)
+		template = S200ParamMeter
 	case S200Smart:
-		c.SetParameter(S200SmartParamMeter)
+		template = S200SmartParamMeter
 	}
-	c.param[len(c.param)-1].Data[len(c.param[len(c.param)-1].Data)-1] = s.rack*0x20 + s.slot
+	// the templates are shared by all connections and must not be modified
+	param := make([]CotpParameter, len(template))
+	for index, singleParam := range template {
+		param[index] = CotpParameter{singleParam.ParamCode, singleParam.ParamLength, append([]byte{}, singleParam.Data...)}
+		switch singleParam.ParamCode {
+		case SrcTSAP:
+			if s.srcTSAP != 0 {
+				binary.BigEndian.PutUint16(param[index].Data, s.srcTSAP)
+			}
+		case DstTSAP:
+			if s.dstTSAP != 0 {
+				binary.BigEndian.PutUint16(param[index].Data, s.dstTSAP)
+			} else if _, ok := defaultSlot[plcType2]; ok {
+				param[index].Data[1] = s.rack*0x20 + s.slot
+			}
+		}
+	}
+	c.SetParameter(param)
 	cB := c.Byte()
 	tB := NewTPKT().SetLength(c.Length() + 5).SetVersion(3).Byte()
 
@@ -251,6 +295,17 @@ func (s *S7Comm) GetCoTPShakeHands(plcType2 plcType) []byte {
 	return shakeHands
 }
 
+// ParseCoTPShakeHands checks the plc confirmed the connection request
+func (s *S7Comm) ParseCoTPShakeHands(i []byte) error {
+	if len(i) < TPKTLength+2 {
+		return fmt.Errorf("invalid length of bytes")
+	}
+	if pduType(i[TPKTLength+1]) != connectConfirmCC {
+		return fmt.Errorf("connection request rejected, pdu type: %X", i[TPKTLength+1])
+	}
+	return nil
+}
+
 func (s *S7Comm) headerByte() []byte {
 	h := s.header
 	return []byte{h.protocolId, byte(h.msgType), h.reserved1, h.reserved2,
@@ -295,30 +350,59 @@ func (s *S7Comm) GetCommunicationByte() []byte {
 }
 
 func (s *S7Comm) ReadVar(size TransportSize, sizeCount int, dbNum int, area Area, address1 int, address2 int) []byte {
+	return s.frame(s.readVarPDU(size, sizeCount, dbNum, area, address1, address2))
+}
+
+// readVarPDU builds the s7comm header and parameters of a read request without TPKT and COTP
+func (s *S7Comm) readVarPDU(size TransportSize, sizeCount int, dbNum int, area Area, address1 int, address2 int) []byte {
+	return s.readVarsPDU([]Item{{Size: size, Count: sizeCount, DBNum: dbNum, Area: area, Address: address1, BitAddress: address2}})
+}
+
+func (s *S7Comm) readVarsPDU(items []Item) []byte {
+	s.header = s.jobHeader()
+	s.parameter = s7CommParameter{
+		funcCode: FuncCodeReadVar,
+		item:     itemsParameter(items),
+	}
+	paramsByte := s.parameterByte()
+	s.header.paramsLength1 = byte(len(paramsByte) / 256)
+	s.header.paramsLength2 = byte(len(paramsByte) % 256)
+	result := s.headerByte()
+	result = append(result, paramsByte...)
+	return result
+}
+
+func (s *S7Comm) jobHeader() s7CommHeader {
 	r := s.random.Int31n(65535)
-	s.header = s7CommHeader{
+	return s7CommHeader{
 		protocolId: 0x32,
 		msgType:    MsgTypeJobRequest,
 		reserved1:  0x00, reserved2: 0x00,
 		pduRef1: byte(r >> 8), pduRef2: byte(r & 0x00ff),
 		paramsLength1: 0, paramsLength2: 0, dataLength1: 0, dataLength2: 0,
 	}
-	address := (address1 << 3) | address2
-	bA := make([]byte, 4)
-	binary.BigEndian.PutUint32(bA, uint32(address))
-	s.parameter = s7CommParameter{
-		funcCode:  FuncCodeReadVar,
-		itemCount: 0,
-		item: []s7CommItem{{
+}
+
+func itemsParameter(items []Item) []s7CommItem {
+	result := make([]s7CommItem, 0, len(items))
+	for _, singleItem := range items {
+		address := (singleItem.Address << 3) | singleItem.BitAddress
+		bA := make([]byte, 4)
+		binary.BigEndian.PutUint32(bA, uint32(address))
+		result = append(result, s7CommItem{
 			varSpec:       0x12,
 			syntaxId:      SyntaxIdS7Any,
-			transportSize: size,
-			length1:       byte(sizeCount / 256), length2: byte(sizeCount % 256),
-			dbNum1: byte(dbNum >> 8), dbNum2: byte(dbNum & 0x00ff),
-			area:    area,
+			transportSize: singleItem.Size,
+			length1:       byte(singleItem.Count / 256), length2: byte(singleItem.Count % 256),
+			dbNum1: byte(singleItem.DBNum >> 8), dbNum2: byte(singleItem.DBNum & 0x00ff),
+			area:    singleItem.Area,
 			address: []byte{bA[1], bA[2], bA[3]},
-		}},
+		})
 	}
+	return result
+}
+
+func (s *S7Comm) parameterByte() []byte {
 	s.parameter.itemCount = byte(len(s.parameter.item))
 	paramsByte := []byte{byte(s.parameter.funcCode), s.parameter.itemCount}
 	for _, singleItem := range s.parameter.item {
@@ -328,21 +412,22 @@ func (s *S7Comm) ReadVar(size TransportSize, sizeCount int, dbNum int, area Area
 		paramsByte = append(paramsByte, singleItem.varSpec, byte(len(variableByte)))
 		paramsByte = append(paramsByte, variableByte...)
 	}
-	s.header.paramsLength1 = byte(len(paramsByte) / 256)
-	s.header.paramsLength2 = byte(len(paramsByte) % 256)
-	headerByte := s.headerByte()
+	return paramsByte
+}
+
+// frame wraps the s7comm pdu with TPKT and COTP
+func (s *S7Comm) frame(pdu []byte) []byte {
 	cotpByte := s.cotp.SetPduType(DataDT).Byte()
 	tpktByte := s.tpkt.SetVersion(0x03).Byte()
 	result := tpktByte
 	result = append(result, cotpByte...)
-	result = append(result, headerByte...)
-	result = append(result, paramsByte...)
+	result = append(result, pdu...)
 	result[2] = byte(len(result) / 256)
 	result[3] = byte(len(result) % 256)
 	return result
 }
 func (s *S7Comm) Parse(i []byte) ([]byte, error) {
-	if len(i) < 4 {
+	if len(i) < TPKTLength+1 {
 		return nil, fmt.Errorf("invalid length of bytes")
 	}
 	length := binary.BigEndian.Uint16(i[2:4])
@@ -350,10 +435,23 @@ func (s *S7Comm) Parse(i []byte) ([]byte, error) {
 		return nil, errors.New(fmt.Sprintf("incomplete data,require %d but got %d", length, len(i)))
 	}
 	cotpLength := i[4]
-	s7CommTotal := i[tPKTLength+cotpLength+1:]
+	if len(i) < TPKTLength+int(cotpLength)+1 {
+		return nil, fmt.Errorf("invalid length of bytes")
+	}
+	return parsePDU(i[TPKTLength+cotpLength+1:])
+}
+
+// parsePDU checks the s7comm ack data and returns the data of the first item
+func parsePDU(s7CommTotal []byte) ([]byte, error) {
+	if len(s7CommTotal) < 12 {
+		return nil, fmt.Errorf("invalid length of s7comm pdu: %d", len(s7CommTotal))
+	}
 	s7Header := s7CommTotal[:12]
 	parameterLength := binary.BigEndian.Uint16(s7Header[6:8])
 	s7DataLength := binary.BigEndian.Uint16(s7Header[8:10])
+	if len(s7CommTotal) < len(s7Header)+int(parameterLength)+int(s7DataLength) {
+		return nil, fmt.Errorf("incomplete s7comm pdu: %d", len(s7CommTotal))
+	}
 	s7Parameter := s7CommTotal[len(s7Header) : len(s7Header)+int(parameterLength)]
 	s7CommData := s7CommTotal[len(s7Header)+len(s7Parameter):]
 	errorClass := s7Header[10]
@@ -366,11 +464,7 @@ func (s *S7Comm) Parse(i []byte) ([]byte, error) {
 	}
 	dataCode := s7CommData[0]
 	if dataCode != 0xff {
-		if returnDataString, ok := returnDataCode[dataCode]; ok {
-			return nil, fmt.Errorf("%s(%X)", returnDataString, dataCode)
-		} else {
-			return nil, fmt.Errorf("%s(%X)", "unkown error", dataCode)
-		}
+		return nil, returnCodeError(dataCode)
 	}
 	if s7DataLength >= 5 {
 		return s7CommData[4:], nil
@@ -378,67 +472,49 @@ func (s *S7Comm) Parse(i []byte) ([]byte, error) {
 	return nil, nil
 }
 func (s *S7Comm) WriteVar(size TransportSize, sizeCount int, dbNum int, area Area, address1 int, address2 int, data []byte) []byte {
-	r := s.random.Int31n(65535)
-	s.header = s7CommHeader{
-		protocolId: 0x32,
-		msgType:    MsgTypeJobRequest,
-		reserved1:  0x00, reserved2: 0x00,
-		pduRef1: byte(r >> 8), pduRef2: byte(r & 0x00ff),
-		paramsLength1: 0, paramsLength2: 0, dataLength1: 0, dataLength2: 0,
-	}
-	address := (address1 << 3) | address2
-	bA := make([]byte, 4)
-	binary.BigEndian.PutUint32(bA, uint32(address))
+	return s.frame(s.writeVarPDU(size, sizeCount, dbNum, area, address1, address2, data))
+}
+
+// writeVarPDU builds the s7comm header, parameters and data of a write request without TPKT and COTP
+func (s *S7Comm) writeVarPDU(size TransportSize, sizeCount int, dbNum int, area Area, address1 int, address2 int, data []byte) []byte {
+	return s.writeVarsPDU([]Item{{Size: size, Count: sizeCount, DBNum: dbNum, Area: area, Address: address1, BitAddress: address2, Data: data}})
+}
+
+func (s *S7Comm) writeVarsPDU(items []Item) []byte {
+	s.header = s.jobHeader()
 	s.parameter = s7CommParameter{
-		funcCode:  FuncCodeWriteVar,
-		itemCount: 0,
-		item: []s7CommItem{{
-			varSpec:       0x12,
-			syntaxId:      SyntaxIdS7Any,
-			transportSize: size,
-			length1:       byte(sizeCount / 256), length2: byte(sizeCount % 256),
-			dbNum1: byte(dbNum >> 8), dbNum2: byte(dbNum & 0x00ff),
-			area:    area,
-			address: []byte{bA[1], bA[2], bA[3]},
-		}},
+		funcCode: FuncCodeWriteVar,
+		item:     itemsParameter(items),
 	}
-	s.data = s7Data{
-		returnCode: 0x00,
-		data:       data,
-	}
-	if size == TransportSizeBit {
-		s.data.transportSize = DataTransportSizeBBit
-		s.data.length1 = byte(len(data) / 256)
-		s.data.length2 = byte(len(data) % 256)
-	} else {
-		s.data.transportSize = DataTransportSizeBInt
-		s.data.length1 = byte(len(data) * 8 / 256)
-		s.data.length2 = byte(len(data) * 8 % 256)
+	var dataByte []byte
+	for index, singleItem := range items {
+		s.data = s7Data{
+			returnCode: 0x00,
+			data:       singleItem.Data,
+		}
+		if singleItem.Size == TransportSizeBit {
+			s.data.transportSize = DataTransportSizeBBit
+			s.data.length1 = byte(len(singleItem.Data) / 256)
+			s.data.length2 = byte(len(singleItem.Data) % 256)
+		} else {
+			s.data.transportSize = DataTransportSizeBInt
+			s.data.length1 = byte(len(singleItem.Data) * 8 / 256)
+			s.data.length2 = byte(len(singleItem.Data) * 8 % 256)
+		}
+		dataByte = append(dataByte, s.data.returnCode, byte(s.data.transportSize), s.data.length1, s.data.length2)
+		dataByte = append(dataByte, s.data.data...)
+		// every item but the last is padded to an even length
+		if len(s.data.data)%2 != 0 && index != len(items)-1 {
+			dataByte = append(dataByte, 0)
+		}
 	}
-	dataByte := []byte{s.data.returnCode, byte(s.data.transportSize), s.data.length1, s.data.length2}
-	dataByte = append(dataByte, s.data.data...)
 	s.header.dataLength1 = byte(len(dataByte) / 256)
 	s.header.dataLength2 = byte(len(dataByte) % 256)
-	s.parameter.itemCount = byte(len(s.parameter.item))
-	paramsByte := []byte{byte(s.parameter.funcCode), s.parameter.itemCount}
-	for _, singleItem := range s.parameter.item {
-		variableByte := []byte{byte(singleItem.syntaxId), byte(singleItem.transportSize), singleItem.length1, singleItem.length2,
-			singleItem.dbNum1, singleItem.dbNum2, byte(singleItem.area)}
-		variableByte = append(variableByte, singleItem.address...)
-		paramsByte = append(paramsByte, singleItem.varSpec, byte(len(variableByte)))
-		paramsByte = append(paramsByte, variableByte...)
-	}
+	paramsByte := s.parameterByte()
 	s.header.paramsLength1 = byte(len(paramsByte) / 256)
 	s.header.paramsLength2 = byte(len(paramsByte) % 256)
-	headerByte := s.headerByte()
-	cotpByte := s.cotp.SetPduType(DataDT).Byte()
-	tpktByte := s.tpkt.SetVersion(0x03).Byte()
-	result := tpktByte
-	result = append(result, cotpByte...)
-	result = append(result, headerByte...)
+	result := s.headerByte()
 	result = append(result, paramsByte...)
 	result = append(result, dataByte...)
-	result[2] = byte(len(result) / 256)
-	result[3] = byte(len(result) % 256)
 	return result
 }
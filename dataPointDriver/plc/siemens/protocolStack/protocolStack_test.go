@@ -0,0 +1,89 @@
+package protocolStack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// s7PduRefOffset the pdu reference in a request, after TPKT, COTP and the first 4 bytes of the s7comm header
+const s7PduRefOffset = 11
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+// clearPduRef the pdu reference of a request is random, it is compared as 0
+func clearPduRef(frame []byte, offset int) []byte {
+	result := append([]byte{}, frame...)
+	result[offset], result[offset+1] = 0, 0
+	return result
+}
+
+func TestS7ReadWriteVar(t *testing.T) {
+	tests := []struct {
+		name string
+		got  func(s *S7Comm) []byte
+		want string
+	}{
+		{
+			name: "read DB1.DBW0",
+			got:  func(s *S7Comm) []byte { return s.ReadVar(Word, 1, 1, AreaTypeDB, 0, 0) },
+			want: "0300001F 02F080 3201 0000 0000 000E 0000 0401 120A10 04 0001 0001 84 000000",
+		},
+		{
+			name: "read M10.3",
+			got:  func(s *S7Comm) []byte { return s.ReadVar(TransportSizeBit, 1, 0, AreaTypeFLAGS, 10, 3) },
+			want: "0300001F 02F080 3201 0000 0000 000E 0000 0401 120A10 01 0001 0000 83 000053",
+		},
+		{
+			name: "write DB1.DBW2",
+			got:  func(s *S7Comm) []byte { return s.WriteVar(Word, 1, 1, AreaTypeDB, 2, 0, []byte{0x12, 0x34}) },
+			want: "03000025 02F080 3201 0000 0000 000E 0006 0501 120A10 04 0001 0001 84 000010 00 05 0010 1234",
+		},
+		{
+			name: "write Q0.1",
+			got:  func(s *S7Comm) []byte { return s.WriteVar(TransportSizeBit, 1, 0, AreaTypeOUTPUTS, 0, 1, []byte{0x01}) },
+			want: "03000024 02F080 3201 0000 0000 000E 0005 0501 120A10 01 0001 0000 82 000001 00 03 0001 01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clearPduRef(tt.got(NewS7Comm(0, 1)), s7PduRefOffset)
+			if want := decodeHex(t, tt.want); !bytes.Equal(got, want) {
+				t.Fatalf("got % X, want % X", got, want)
+			}
+		})
+	}
+}
+
+func TestS7Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{"word", "0300001B 02F080 3203 0000 0001 0002 0006 0000 0401 FF04 0010 1234", "1234", false},
+		{"object does not exist", "03000016 02F080 3203 0000 0001 0002 0001 0000 0401 0A", "", true},
+		{"error class", "03000013 02F080 3203 0000 0001 0000 0000 8104", "", true},
+		{"incomplete", "0300001B 02F080 3203 0000 0001 0002 0006 0000 0401 FF04", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewS7Comm(0, 1).Parse(decodeHex(t, tt.response))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, decodeHex(t, tt.want)) {
+				t.Fatalf("Parse() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+}
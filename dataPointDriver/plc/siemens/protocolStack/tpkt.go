@@ -1,5 +1,10 @@
 package protocolStack
 
+import (
+	"encoding/binary"
+	"fmt"
+)
+
 type Tpkt struct {
 	version  byte
 	reserved byte
@@ -28,3 +33,15 @@ func NewTPKT() *Tpkt {
 	t := Tpkt{}
 	return &t
 }
+
+// FrameLength the length of the whole frame given in its TPKT header
+func FrameLength(header []byte) (int, error) {
+	if len(header) < TPKTLength || header[0] != 3 {
+		return 0, fmt.Errorf("invalid tpkt header: % X", header)
+	}
+	length := int(binary.BigEndian.Uint16(header[2:4]))
+	if length < TPKTLength {
+		return 0, fmt.Errorf("invalid tpkt length: %d", length)
+	}
+	return length, nil
+}
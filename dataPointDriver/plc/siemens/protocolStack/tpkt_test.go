@@ -0,0 +1,29 @@
+package protocolStack
+
+import "testing"
+
+func TestFrameLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{"pdu of 240", "030000F0", 240, false},
+		{"pdu of 960", "030003C0", 960, false},
+		{"not tpkt", "320100F0", 0, true},
+		{"shorter than the header", "03000002", 0, true},
+		{"incomplete", "0300", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FrameLength(decodeHex(t, tt.header))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FrameLength() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("FrameLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
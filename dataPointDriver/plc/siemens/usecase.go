@@ -1,33 +1,36 @@
 package siemens
 
 import (
+	"didaGatewayCenter/dataPointDriver/connection"
 	"didaGatewayCenter/dataPointDriver/plc/siemens/protocolStack"
+	"didaGatewayCenter/dataTransform"
 	"didaGatewayCenter/domain"
 	"didaGatewayCenter/net"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
-	net2 "net"
-	"os"
-	"reflect"
-	"syscall"
+	"sync"
 	"time"
 )
 
 type siemens struct {
-	s            *protocolStack.S7Comm
-	isConnected  bool
-	iLogU        domain.ILogUsecase
-	iDTU         domain.IDataTransformUsecase
-	conn         domain.Software
-	portConfig   *domain.DataPointPortConfig
-	timeoutCount int
+	s           *protocolStack.S7Comm
+	isConnected bool
+	iLogU       domain.ILogUsecase
+	iDTU        domain.IDataTransformUsecase
+	conn        domain.Software
+	portConfig  *domain.DataPointPortConfig
+	monitor     connection.Monitor
+	policy      connection.Policy
+	lock        sync.Mutex
 }
 
 func (s *siemens) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
 
 	s.portConfig = portConfig
 	s.iDTU = transform
+	s.policy = connection.NewPolicy(portConfig.Param.Reconnect)
+	s.monitor.SetMaxTimeouts(s.policy.MaxTimeouts)
 
 	go func() {
 		s.connect()
@@ -36,203 +39,291 @@ func (s *siemens) Init(portConfig *domain.DataPointPortConfig, transform domain.
 	return
 }
 func (s *siemens) connect() {
-	//TODO: set rack and slot
-	s7 := protocolStack.NewS7Comm(0, 0x0a)
-	s.s = s7
-	b := s7.GetCoTPShakeHands(protocolStack.S200Smart)
-	b2 := s7.GetCommunicationByte()
+	param := s.portConfig.Param
+	plcType, err := getPlcType(s.portConfig.DeviceType)
+	if err != nil {
+		s.iLogU.GetLogger().Error("siemens plc cannot be connected", zap.String("portName", s.portConfig.PortName), zap.Error(err))
+		return
+	}
+	rack, slot := byte(0), protocolStack.GetDefaultSlot(plcType)
+	if param.Rack != nil {
+		rack = byte(*param.Rack)
+	}
+	if param.Slot != nil {
+		slot = byte(*param.Slot)
+	}
 
 	address := fmt.Sprintf("%s:%d", s.portConfig.Param.IP, s.portConfig.Param.PortNumber)
 	s.iLogU.GetLogger().Info("siemens plc is connecting", zap.String("portName", s.portConfig.PortName))
-	//	interval := 0
+	logger := s.iLogU.GetLogger()
+	backoff := connection.NewBackoff(s.policy)
 	for {
 		if s.isConnected {
 			time.Sleep(time.Second)
 			continue
 		}
-		/*	interval += 3
-			if interval >= 60 {
-				interval = 60
-			}
-			time.Sleep(time.Duration(interval) * time.Second)*/
-		tcpConn, err := net.Dial("tcp", address)
+		s.lock.Lock()
+		net.Close(s.conn)
+		s.lock.Unlock()
+		tcpConn, err := net.Dial("tcp", address, s.policy.DialTimeout)
 		if err != nil {
-			time.Sleep(time.Second)
-			//	s.iLogU.GetLogger().Warn("cannot connect to plc", zap.String("name", s.portConfig.PortName), zap.String("address", address), zap.Error(err))
+			backoff.Wait(logger, "cannot connect to plc", err, zap.String("name", s.portConfig.PortName), zap.String("address", address))
 			continue
 		}
-		if _, err := tcpConn.WriteReadTimeout(b, time.Second); err != nil {
-			s.iLogU.GetLogger().Warn("send cotp failed", zap.String("name", s.portConfig.PortName), zap.String("address", address), zap.Error(err))
+		// every connection negotiates its own pdu length, the stack in use is only replaced under the lock
+		s7 := protocolStack.NewS7Comm(rack, slot).SetTSAP(uint16(param.SrcTSAP), uint16(param.DstTSAP))
+		if r, err := exchange(tcpConn, s7.GetCoTPShakeHands(plcType), connection.RespTimeout(param)); err != nil {
+			net.Close(tcpConn)
+			backoff.Wait(logger, "send cotp failed", err, zap.String("name", s.portConfig.PortName), zap.String("address", address))
+			continue
+		} else if err := s7.ParseCoTPShakeHands(r); err != nil {
+			// usually wrong rack/slot, or PUT/GET access is not permitted on S7-1200/1500
+			net.Close(tcpConn)
+			backoff.Wait(logger, "cotp connection refused", err, zap.String("name", s.portConfig.PortName), zap.String("address", address),
+				zap.Int("rack", int(rack)), zap.Int("slot", int(slot)))
 			continue
 		}
-		if _, err := tcpConn.WriteReadTimeout(b2, time.Second); err != nil {
-			s.iLogU.GetLogger().Warn("send setCommunication failed", zap.String("name", s.portConfig.PortName), zap.String("address", address), zap.Error(err))
+		if r, err := exchange(tcpConn, s7.GetCommunicationByte(), connection.RespTimeout(param)); err != nil {
+			net.Close(tcpConn)
+			backoff.Wait(logger, "send setCommunication failed", err, zap.String("name", s.portConfig.PortName), zap.String("address", address))
+			continue
+		} else if err := s7.ParseCommunication(r); err != nil {
+			net.Close(tcpConn)
+			backoff.Wait(logger, "setCommunication rejected", err, zap.String("name", s.portConfig.PortName), zap.String("address", address))
 			continue
 		}
-		s.iLogU.GetLogger().Info("siemens plc connected", zap.String("name", s.portConfig.PortName), zap.String("address", address))
-		//	interval = 0
+		s.iLogU.GetLogger().Info("siemens plc connected", zap.String("name", s.portConfig.PortName), zap.String("address", address),
+			zap.Int("pduLength", s7.PduLength()), zap.Int("attempts", backoff.Attempts()+1))
+		backoff.Reset()
+		s.lock.Lock()
+		s.s = s7
 		s.conn = tcpConn
+		s.lock.Unlock()
 		s.isConnected = true
+		s.monitor.Connected()
 	}
 }
-func (s *siemens) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) domain.IValueType {
+func (s *siemens) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
 
 	//	log.Println(variableList.Name, variableList.Id)
 	if !s.isConnected {
 		time.Sleep(time.Second)
-		return nil
+		return nil, connection.ErrNotConnected
 	}
-	dataType := variableList.DataType
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	regType := variableList.Param.RegType
 	regAddr := variableList.Param.RegAddr
-	dbNum := variableList.Param.DBNum
+	dbNum := getDBNum(regType, variableList.Param.DBNum)
 	bitAddress := variableList.Param.BitAddr
 
-	is200family := false
-	switch portInfo.DeviceType {
-	case domain.DeviceTypeSiemensS200Smart, domain.DeviceTypeSiemens200CP2431:
-		is200family = true
-	default:
-		is200family = false
-	}
-	sizeType, sizeCount := getTransportSize(dataType)
+	is200family := isS200Family(portInfo.DeviceType)
+	sizeType, sizeCount := getTransportSize(variableList)
 	area := getArea(regType, is200family)
 
 	bb := s.s.ReadVar(sizeType, sizeCount, dbNum, area, regAddr, bitAddress)
-	r, err := s.conn.WriteReadTimeout(bb, time.Second)
+	s.monitor.Begin()
+	r, err := exchange(s.conn, bb, connection.RespTimeout(portInfo.Param))
 
 	if err != nil {
-		if errors.Is(err, os.ErrDeadlineExceeded) {
-			s.timeoutCount++
-			if s.timeoutCount == 5 {
-				s.timeoutCount = 0
-				s.isConnected = false
-				s.iLogU.GetLogger().Warn("s7net timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-			} else {
-				s.iLogU.GetLogger().Warn("read from s7net timeout", zap.String("portName", portInfo.PortName),
-					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-			}
-			return nil
-		}
-		if opErr, ok := err.(*net2.OpError); ok {
-			switch t := opErr.Err.(type) {
-			case *os.SyscallError:
-				if errno, ok := t.Err.(syscall.Errno); ok {
-					switch errno {
-					case syscall.ECONNABORTED:
-						s.isConnected = false
-						s.iLogU.GetLogger().Warn("s7net connection aborted,reconnecting")
-					default:
-						s.iLogU.GetLogger().Warn("unexpected syscall error occurred while reading s7net", zap.String("portName", portInfo.PortName),
-							zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-					}
-				}
-			default:
-				s.iLogU.GetLogger().Warn("unknown operation error", zap.String("error type", reflect.ValueOf(opErr.Err).String()), zap.Error(err))
-			}
-		} else {
-			s.iLogU.GetLogger().Warn("error occurred while reading s7net", zap.String("portName", portInfo.PortName),
-				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-		}
+		return nil, s.checkError(portInfo, deviceInfo, variableList.Name, err)
 	}
 	valueByte, err := s.s.Parse(r)
 	if err != nil {
 		s.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
-		return nil
+		return nil, parseError(err)
 	}
-	if len(valueByte) == 1 {
+	s.monitor.Succeeded()
+	if len(valueByte) == 1 && variableList.DataType != domain.VarDataTypeString {
 		valueByte = append([]byte{0}, valueByte[0])
 	}
 	value, err := s.iDTU.ByteToValue(deviceInfo, variableList, valueByte)
 	if err != nil {
 		s.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
 	}
-	return value
+	return value, nil
 }
 
 func (s *siemens) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
 	if !s.isConnected {
-		time.Sleep(time.Second)
-		return fmt.Errorf("s7net device is not connected")
+		return connection.ErrNotConnected
 	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
 	dataType := variableInfo.DataType
 	regType := variableInfo.Param.RegType
 	regAddr := variableInfo.Param.RegAddr
-	dbNum := variableInfo.Param.DBNum
+	dbNum := getDBNum(regType, variableInfo.Param.DBNum)
 	bitAddress := variableInfo.Param.BitAddr
 
-	is200family := false
-	switch portInfo.DeviceType {
-	case domain.DeviceTypeSiemensS200Smart, domain.DeviceTypeSiemens200CP2431:
-		is200family = true
-	default:
-		is200family = false
-	}
-	sizeType, sizeCount := getTransportSize(dataType)
+	is200family := isS200Family(portInfo.DeviceType)
+	sizeType, sizeCount := getTransportSize(variableInfo)
 	area := getArea(regType, is200family)
 
-	result, err := s.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue.(float64))
+	result, err := s.iDTU.ValueToByte(deviceInfo, variableInfo, inputValue)
 	if err != nil {
-		panic(err)
+		return connection.InvalidAddress("%v", err)
 	}
 	if dataType == domain.VarDataTypeBit {
 		result = []byte{result[1]}
 	}
 	r1 := s.s.WriteVar(sizeType, sizeCount, dbNum, area, regAddr, bitAddress, result)
-	r, err := s.conn.WriteReadTimeout(r1, time.Second)
+	s.monitor.Begin()
+	r, err := exchange(s.conn, r1, connection.RespTimeout(portInfo.Param))
 	if err != nil {
-		if errors.Is(err, os.ErrDeadlineExceeded) {
-			s.timeoutCount++
-			if s.timeoutCount == 5 {
-				s.timeoutCount = 0
-				s.isConnected = false
-				s.iLogU.GetLogger().Warn("s7net timeout times have exceeded the limit, reconnecting", zap.String("portName", portInfo.PortName))
-			} else {
-				s.iLogU.GetLogger().Warn("read from s7net timeout", zap.String("portName", portInfo.PortName),
-					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-			}
-			return nil
-		}
-		if opErr, ok := err.(*net2.OpError); ok {
-			switch t := opErr.Err.(type) {
-			case *os.SyscallError:
-				if errno, ok := t.Err.(syscall.Errno); ok {
-					switch errno {
-					case syscall.ECONNABORTED:
-						s.isConnected = false
-						s.iLogU.GetLogger().Warn("s7net connection aborted,reconnecting")
-					default:
-						s.iLogU.GetLogger().Warn("unexpected syscall error occurred while reading s7net", zap.String("portName", portInfo.PortName),
-							zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-					}
-				}
-			default:
-				s.iLogU.GetLogger().Warn("unknown operation error", zap.String("error type", reflect.ValueOf(opErr.Err).String()), zap.Error(err))
-			}
-		} else {
-			s.iLogU.GetLogger().Warn("error occurred while reading s7net", zap.String("portName", portInfo.PortName),
-				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
-		}
+		return s.checkError(portInfo, deviceInfo, variableInfo.Name, err)
 	}
 	if _, err := s.s.Parse(r); err != nil {
 		s.iLogU.GetLogger().Warn("s7net write failed", zap.String("portName", portInfo.PortName),
 			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableInfo.Name), zap.Error(err))
+		return parseError(err)
 	}
+	s.monitor.Succeeded()
 	return nil
 }
 
+// ReadBlock reads the variables of a device with multi-item requests, as many items as the negotiated pdu length
+// allows are sent in one request
+func (s *siemens) ReadBlock(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList []*domain.DataPointVariableList) ([]domain.IValueType, []error) {
+	values := make([]domain.IValueType, len(variableList))
+	errs := make([]error, len(variableList))
+	if !s.isConnected {
+		time.Sleep(time.Second)
+		for index := range errs {
+			errs[index] = connection.ErrNotConnected
+		}
+		return values, errs
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	is200family := isS200Family(portInfo.DeviceType)
+	items := make([]protocolStack.Item, len(variableList))
+	for index, variable := range variableList {
+		sizeType, sizeCount := getTransportSize(variable)
+		items[index] = protocolStack.Item{
+			Size:       sizeType,
+			Count:      sizeCount,
+			DBNum:      getDBNum(variable.Param.RegType, variable.Param.DBNum),
+			Area:       getArea(variable.Param.RegType, is200family),
+			Address:    variable.Param.RegAddr,
+			BitAddress: variable.Param.BitAddr,
+		}
+	}
+	groups, oversized := s.s.SplitRead(items)
+	for _, index := range oversized {
+		errs[index] = connection.InvalidAddress("variable %s does not fit in the pdu of %d bytes", variableList[index].Name, s.s.PduLength())
+	}
+	for _, group := range groups {
+		if !s.isConnected {
+			setError(errs, group, connection.ErrNotConnected)
+			continue
+		}
+		groupItems := make([]protocolStack.Item, len(group))
+		for i, index := range group {
+			groupItems[i] = items[index]
+		}
+		s.monitor.Begin()
+		r, err := exchange(s.conn, s.s.ReadVars(groupItems), connection.RespTimeout(portInfo.Param))
+		if err != nil {
+			setError(errs, group, s.checkError(portInfo, deviceInfo, variableList[group[0]].Name, err))
+			continue
+		}
+		results, err := s.s.ParseReadVars(r)
+		if err != nil {
+			s.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+				zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList[group[0]].Name),
+				zap.Int("itemCount", len(group)), zap.Error(err))
+			setError(errs, group, parseError(err))
+			continue
+		}
+		s.monitor.Succeeded()
+		for i, index := range group {
+			variable := variableList[index]
+			if i >= len(results) {
+				errs[index] = connection.InvalidResponse(fmt.Errorf("missing data of item %d", i))
+				continue
+			}
+			result := results[i]
+			if result.Err != nil {
+				s.iLogU.GetLogger().Warn("parse data failed", zap.String("portName", portInfo.PortName),
+					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variable.Name), zap.Error(result.Err))
+				errs[index] = parseError(result.Err)
+				continue
+			}
+			valueByte := result.Data
+			if len(valueByte) == 1 && variable.DataType != domain.VarDataTypeString {
+				valueByte = append([]byte{0}, valueByte[0])
+			}
+			value, err := s.iDTU.ByteToValue(deviceInfo, variable, valueByte)
+			if err != nil {
+				s.iLogU.GetLogger().Warn("Error converting variable value", zap.String("portName", portInfo.PortName),
+					zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variable.Name), zap.Error(err))
+				errs[index] = connection.InvalidResponse(err)
+				continue
+			}
+			values[index] = value
+		}
+	}
+	return values, errs
+}
+
+// Health the health of the connection of the port
+func (s *siemens) Health() domain.PortHealth {
+	return s.monitor.Health()
+}
+
+// checkError classifies and logs the error of a request, the connection is reopened when it is lost or times out
+// too often
+func (s *siemens) checkError(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableName string, err error) error {
+	driverErr := connection.Classify(err)
+	if s.monitor.Failed(driverErr) {
+		s.isConnected = false
+		s.monitor.Disconnected()
+		s.iLogU.GetLogger().Warn("s7net connection lost, reconnecting", zap.String("portName", portInfo.PortName), zap.Error(driverErr))
+		return driverErr
+	}
+	s.iLogU.GetLogger().Warn("s7net request failed", zap.String("portName", portInfo.PortName),
+		zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableName), zap.Error(driverErr))
+	return driverErr
+}
+
+// exchange sends a request and reads the response of the length in its TPKT header, the responses of multi-item
+// requests are as long as the pdu and may arrive in several segments
+func exchange(conn domain.Software, request []byte, timeout time.Duration) ([]byte, error) {
+	if tcpConn, ok := conn.(*net.Tcp); ok {
+		return tcpConn.WriteReadFrame(request, protocolStack.TPKTLength, protocolStack.FrameLength, timeout)
+	}
+	return conn.WriteReadTimeout(request, timeout)
+}
+
+// parseError the return codes of a response are exceptions of the plc
+func parseError(err error) error {
+	var returnCodeErr protocolStack.ReturnCodeError
+	if errors.As(err, &returnCodeErr) {
+		return connection.Exception(int(returnCodeErr.Code), err)
+	}
+	return connection.InvalidResponse(err)
+}
+
+func setError(errs []error, indexes []int, err error) {
+	for _, index := range indexes {
+		errs[index] = err
+	}
+}
+
 func NewSiemensDriver(iLogU domain.ILogUsecase) domain.IDataPointDriverUsecase {
 	s := siemens{
 		iLogU: iLogU,
 	}
 	return &s
 }
-func getTransportSize(dataType domain.DataType) (sizeType protocolStack.TransportSize, sizeCount int) {
+func getTransportSize(variableList *domain.DataPointVariableList) (sizeType protocolStack.TransportSize, sizeCount int) {
 	sizeCount = 1
-	switch dataType {
+	switch variableList.DataType {
 	case domain.VarDataTypeBit, domain.VarDataTypeBool:
 		sizeType = protocolStack.TransportSizeBit
 	case domain.VarDataTypeByte:
@@ -240,14 +331,47 @@ func getTransportSize(dataType domain.DataType) (sizeType protocolStack.Transpor
 	case domain.VarDataTypeUint16, domain.VarDataTypeInt16:
 		sizeType = protocolStack.Word
 	case domain.VarDataTypeUint32, domain.VarDataTypeInt32, domain.VarDataTypeFloat:
-		sizeType = protocolStack.TransportSizeByte
 		sizeType = protocolStack.DWord
 	case domain.VarDataTypeUint64, domain.VarDataTypeInt64, domain.VarDataTypeDouble:
 		sizeType = protocolStack.DWord
 		sizeCount = 2
+	case domain.VarDataTypeString:
+		sizeType = protocolStack.TransportSizeByte
+		sizeCount = dataTransform.StringSize(variableList)
 	}
 	return
 }
+func getPlcType(deviceType domain.DeviceType) (protocolStack.PlcType, error) {
+	switch deviceType {
+	case domain.DeviceTypeSiemensS200Smart:
+		return protocolStack.S200Smart, nil
+	case domain.DeviceTypeSiemensS300:
+		return protocolStack.S300, nil
+	case domain.DeviceTypeSiemensS400:
+		return protocolStack.S400, nil
+	case domain.DeviceTypeSiemensS1200:
+		return protocolStack.S1200, nil
+	case domain.DeviceTypeSiemensS1500:
+		return protocolStack.S1500, nil
+	}
+	return "", fmt.Errorf("device type %d is not a siemens s7 plc", deviceType)
+}
+
+func isS200Family(deviceType domain.DeviceType) bool {
+	switch deviceType {
+	case domain.DeviceTypeSiemensS200Smart, domain.DeviceTypeSiemens200CP2431:
+		return true
+	}
+	return false
+}
+
+// getDBNum only the DB area is addressed by block number
+func getDBNum(regType domain.RegisterType, dbNum int) int {
+	if regType != domain.RegTypeSiemensDB {
+		return 0
+	}
+	return dbNum
+}
 func getArea(regType domain.RegisterType, is200family bool) (area protocolStack.Area) {
 
 	switch regType {
@@ -0,0 +1,179 @@
+package virtual
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// VariableLookup returns the latest value of the variable with the id
+type VariableLookup func(id int64) (interface{}, error)
+
+// expression evaluates arithmetic expressions of numbers and variables, $12 refers to the variable whose Id is 12,
+// supported operators are + - * / % and parentheses
+type expression struct {
+	input  []rune
+	pos    int
+	lookup VariableLookup
+}
+
+func evaluate(input string, lookup VariableLookup) (float64, error) {
+	e := expression{input: []rune(input), lookup: lookup}
+	value, err := e.sum()
+	if err != nil {
+		return 0, err
+	}
+	if e.skipSpace(); e.pos < len(e.input) {
+		return 0, fmt.Errorf("unexpected %q at %d", e.input[e.pos], e.pos)
+	}
+	return value, nil
+}
+
+func (e *expression) sum() (float64, error) {
+	value, err := e.product()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch e.next() {
+		case '+':
+			e.pos++
+			right, err := e.product()
+			if err != nil {
+				return 0, err
+			}
+			value += right
+		case '-':
+			e.pos++
+			right, err := e.product()
+			if err != nil {
+				return 0, err
+			}
+			value -= right
+		default:
+			return value, nil
+		}
+	}
+}
+
+func (e *expression) product() (float64, error) {
+	value, err := e.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		operator := e.next()
+		if operator != '*' && operator != '/' && operator != '%' {
+			return value, nil
+		}
+		e.pos++
+		right, err := e.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch operator {
+		case '*':
+			value *= right
+		case '/':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			value /= right
+		case '%':
+			if int64(right) == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			value = float64(int64(value) % int64(right))
+		}
+	}
+}
+
+func (e *expression) unary() (float64, error) {
+	switch e.next() {
+	case '-':
+		e.pos++
+		value, err := e.unary()
+		return -value, err
+	case '+':
+		e.pos++
+		return e.unary()
+	}
+	return e.operand()
+}
+
+func (e *expression) operand() (float64, error) {
+	switch r := e.next(); {
+	case r == '(':
+		e.pos++
+		value, err := e.sum()
+		if err != nil {
+			return 0, err
+		}
+		if e.next() != ')' {
+			return 0, fmt.Errorf("missing ) at %d", e.pos)
+		}
+		e.pos++
+		return value, nil
+	case r == '$':
+		e.pos++
+		text := e.scan(unicode.IsDigit)
+		id, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid variable id %q at %d", text, e.pos)
+		}
+		return e.variable(id)
+	case unicode.IsDigit(r) || r == '.':
+		text := e.scan(func(r rune) bool { return unicode.IsDigit(r) || r == '.' })
+		return strconv.ParseFloat(text, 64)
+	case r == 0:
+		return 0, fmt.Errorf("unexpected end of expression")
+	default:
+		return 0, fmt.Errorf("unexpected %q at %d", r, e.pos)
+	}
+}
+
+func (e *expression) variable(id int64) (float64, error) {
+	value, err := e.lookup(id)
+	if err != nil {
+		return 0, fmt.Errorf("variable $%d: %w", id, err)
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return 0, fmt.Errorf("variable $%d has no value", id)
+	}
+	return 0, fmt.Errorf("variable $%d is not a number", id)
+}
+
+// next skips spaces and returns the current character, 0 at the end
+func (e *expression) next() rune {
+	e.skipSpace()
+	if e.pos >= len(e.input) {
+		return 0
+	}
+	return e.input[e.pos]
+}
+
+func (e *expression) skipSpace() {
+	for e.pos < len(e.input) && unicode.IsSpace(e.input[e.pos]) {
+		e.pos++
+	}
+}
+
+func (e *expression) scan(accept func(r rune) bool) string {
+	start := e.pos
+	for e.pos < len(e.input) && accept(e.input[e.pos]) {
+		e.pos++
+	}
+	return string(e.input[start:e.pos])
+}
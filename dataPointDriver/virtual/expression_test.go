@@ -0,0 +1,65 @@
+package virtual
+
+import (
+	"errors"
+	"testing"
+)
+
+func lookup(values map[int64]interface{}) VariableLookup {
+	return func(id int64) (interface{}, error) {
+		value, ok := values[id]
+		if !ok {
+			return nil, errors.New("variable name is not found")
+		}
+		return value, nil
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	values := lookup(map[int64]interface{}{
+		1: float64(2.5),
+		2: int64(-4),
+		3: uint64(10),
+		4: true,
+		5: nil,
+		6: "text",
+	})
+	tests := []struct {
+		name    string
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"number", "42", 42, false},
+		{"precedence", "1 + 2 * 3", 7, false},
+		{"left to right", "10 - 4 - 3", 3, false},
+		{"parentheses", "(1 + 2) * 3", 9, false},
+		{"unary minus", "-(2 + 3) * -2", 10, false},
+		{"modulo", "17 % 5", 2, false},
+		{"variables", "$1 * 2 + $2", 1, false},
+		{"uint64 and bool variables", "$3 + $4", 11, false},
+		{"spaces", "  $1   /  0.5 ", 5, false},
+		{"division by zero", "1 / 0", 0, true},
+		{"division by a zero variable", "$1 / ($2 + 4)", 0, true},
+		{"modulo by zero", "5 % 0.5", 0, true},
+		{"unknown variable", "$99 + 1", 0, true},
+		{"variable without value", "$5", 0, true},
+		{"variable is not a number", "$6", 0, true},
+		{"missing id", "$ + 1", 0, true},
+		{"missing parenthesis", "(1 + 2", 0, true},
+		{"trailing text", "1 + 2 x", 0, true},
+		{"empty", "", 0, true},
+		{"invalid number", "1.2.3", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluate(tt.input, values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("evaluate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
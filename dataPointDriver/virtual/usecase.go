@@ -0,0 +1,135 @@
+package virtual
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/dataTransform"
+	"didaGatewayCenter/domain"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+)
+
+// virtual variables of internal devices are not read from hardware, they hold the values written through MQTT or
+// HTTP, or are computed from other variables when an expression is configured
+type virtual struct {
+	iLogU  domain.ILogUsecase
+	lookup VariableLookup
+	values map[string]interface{}
+	lock   sync.Mutex
+}
+
+func (v *virtual) Init(portConfig *domain.DataPointPortConfig, transform domain.IDataTransformUsecase) {
+	v.iLogU.GetLogger().Info("internal port is ready", zap.String("portName", portConfig.PortName))
+}
+
+// Read written variables start at 0, string variables start empty
+func (v *virtual) Read(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) (domain.IValueType, error) {
+	expression := variableList.Param.Expression
+	if expression == "" {
+		v.lock.Lock()
+		defer v.lock.Unlock()
+		if value, ok := v.values[valueKey(deviceInfo, variableList)]; ok {
+			return dataTransform.NewValue(value), nil
+		}
+		if variableList.DataType == domain.VarDataTypeString {
+			return dataTransform.NewValue(""), nil
+		}
+		return dataTransform.NewValue(native(variableList, 0)), nil
+	}
+	value, err := evaluate(expression, v.lookup)
+	if err != nil {
+		v.iLogU.GetLogger().Warn("compute internal variable failed", zap.String("portName", portInfo.PortName),
+			zap.String("deviceName", deviceInfo.DevName), zap.String("variableName", variableList.Name),
+			zap.String("expression", expression), zap.Error(err))
+		return nil, connection.InvalidResponse(err)
+	}
+	return dataTransform.NewValue(native(variableList, value)), nil
+}
+
+func (v *virtual) Write(portInfo *domain.DataPointPortConfig, deviceInfo *domain.DeviceList, variableInfo *domain.DataPointVariableList, inputValue interface{}) error {
+	if variableInfo.Param.Expression != "" {
+		return connection.InvalidAddress("variable %s is computed and cannot be written", variableInfo.Name)
+	}
+	var value interface{}
+	switch input := inputValue.(type) {
+	case string:
+		if variableInfo.DataType != domain.VarDataTypeString {
+			return connection.InvalidAddress("invalid value type %T", inputValue)
+		}
+		value = input
+	case float64:
+		if variableInfo.DataType == domain.VarDataTypeString {
+			return connection.InvalidAddress("invalid value type %T", inputValue)
+		}
+		value = native(variableInfo, input)
+	case int64, uint64:
+		if variableInfo.DataType == domain.VarDataTypeString {
+			return connection.InvalidAddress("invalid value type %T", inputValue)
+		}
+		// 64 bit integers are kept exactly
+		number := dataTransform.NewValue(input)
+		switch variableInfo.DataType {
+		case domain.VarDataTypeUint64:
+			value = number.ToUint64()
+		case domain.VarDataTypeInt64:
+			value = number.ToInt64()
+		default:
+			value = native(variableInfo, number.ToFloat64())
+		}
+	default:
+		return connection.InvalidAddress("invalid value type %T", inputValue)
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.values[valueKey(deviceInfo, variableInfo)] = value
+	return nil
+}
+
+// Health the internal port has no connection, it is always ready
+func (v *virtual) Health() domain.PortHealth {
+	return domain.PortHealth{Connected: true}
+}
+
+func valueKey(deviceInfo *domain.DeviceList, variableList *domain.DataPointVariableList) string {
+	return deviceInfo.DevName + "/" + variableList.Name
+}
+
+// native keeps the value in the range of the data type, in the type the drivers give for it
+func native(variableList *domain.DataPointVariableList, value float64) interface{} {
+	switch variableList.DataType {
+	case domain.VarDataTypeBool, domain.VarDataTypeBit:
+		return value != 0
+	case domain.VarDataTypeByte:
+		return uint64(uint8(value))
+	case domain.VarDataTypeUint16:
+		return uint64(uint16(value))
+	case domain.VarDataTypeInt16:
+		return int64(int16(value))
+	case domain.VarDataTypeUint32:
+		return uint64(uint32(value))
+	case domain.VarDataTypeInt32:
+		return int64(int32(value))
+	case domain.VarDataTypeUint64:
+		return uint64(value)
+	case domain.VarDataTypeInt64:
+		return int64(value)
+	case domain.VarDataTypeFloat:
+		return round(variableList, float64(float32(value)))
+	}
+	return round(variableList, value)
+}
+
+func round(variableList *domain.DataPointVariableList, value float64) float64 {
+	result, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'f', variableList.Decimal, 64), 64)
+	return result
+}
+
+// NewVirtualDriver lookup gives expressions access to the values of every variable of the gateway
+func NewVirtualDriver(iLogU domain.ILogUsecase, lookup VariableLookup) domain.IDataPointDriverUsecase {
+	v := virtual{
+		iLogU:  iLogU,
+		lookup: lookup,
+		values: map[string]interface{}{},
+	}
+	return &v
+}
@@ -1,26 +1,18 @@
 package dataTransform
 
 import (
+	"bytes"
 	"didaGatewayCenter/domain"
 	"encoding/binary"
 	"fmt"
+	"golang.org/x/text/encoding/simplifiedchinese"
 	"math"
 	"strconv"
 )
 
 type dataTransform struct {
 }
-type valueType struct {
-	input  []byte
-	output float64
-}
 
-func (d *valueType) ToFloat64() float64 {
-	return d.output
-}
-func (d *valueType) OriginalValue() uint16 {
-	return binary.BigEndian.Uint16(d.input)
-}
 func (d *dataTransform) ValueToByte(list *domain.DeviceList, variableList *domain.DataPointVariableList, input interface{}) ([]byte, error) {
 	byteOrder := domain.ByteOrderABCD
 	dataType := variableList.DataType
@@ -28,45 +20,67 @@ func (d *dataTransform) ValueToByte(list *domain.DeviceList, variableList *domai
 	offset := variableList.Offset
 
 	var result []byte
-	inputValue := input.(float64)
+	if dataType == domain.VarDataTypeString {
+		return stringToByte(variableList, input)
+	}
+	var inputValue float64
+	// integers are written exactly unless they are scaled, float64 loses them above 2^53
+	var integer uint64
+	exact := false
+	switch v := input.(type) {
+	case float64:
+		inputValue = v
+	case int64:
+		inputValue, integer, exact = float64(v), uint64(v), true
+	case uint64:
+		inputValue, integer, exact = float64(v), v, true
+	default:
+		return nil, fmt.Errorf("invalid value type %T of %s", input, variableList.Name)
+	}
+	raw := inputValue
 	if offset != 0 {
 		inputValue -= offset
+		exact = false
 	}
 	if modulus != 1 {
 		inputValue /= modulus
+		exact = false
+	}
+	if !exact {
+		integer = toInteger(inputValue)
 	}
 	switch dataType {
 	case domain.VarDataTypeBit:
 		result = make([]byte, 2)
-		binary.BigEndian.PutUint16(result, uint16(input.(float64)))
+		binary.BigEndian.PutUint16(result, uint16(raw))
 		return result, nil
 	case domain.VarDataTypeByte:
 		result = make([]byte, 1)
-		result[0] = byte(input.(float64))
+		result[0] = byte(raw)
 		return result, nil
 	case domain.VarDataTypeBool:
-		if input.(float64) != 0 {
+		if raw != 0 {
 			return []byte{0x00, 0x01}, nil
 		}
 		return []byte{0x00, 0x00}, nil
 	case domain.VarDataTypeUint16, domain.VarDataTypeInt16:
 		result = make([]byte, 2)
-		binary.BigEndian.PutUint16(result, uint16(inputValue))
+		binary.BigEndian.PutUint16(result, uint16(integer))
 	case domain.VarDataTypeUint32, domain.VarDataTypeInt32:
 		result = make([]byte, 4)
 		byteOrder = list.LongOrder
 		if byteOrder == domain.ByteOrderABCD || byteOrder == domain.ByteOrderBADC {
-			binary.BigEndian.PutUint32(result, uint32(inputValue))
+			binary.BigEndian.PutUint32(result, uint32(integer))
 		} else {
-			binary.LittleEndian.PutUint32(result, uint32(inputValue))
+			binary.LittleEndian.PutUint32(result, uint32(integer))
 		}
 	case domain.VarDataTypeUint64, domain.VarDataTypeInt64:
 		result = make([]byte, 8)
 		byteOrder = list.LongLongOrder
 		if byteOrder == domain.ByteOrderABCD || byteOrder == domain.ByteOrderBADC {
-			binary.BigEndian.PutUint64(result, uint64(inputValue))
+			binary.BigEndian.PutUint64(result, integer)
 		} else {
-			binary.LittleEndian.PutUint64(result, uint64(inputValue))
+			binary.LittleEndian.PutUint64(result, integer)
 		}
 	case domain.VarDataTypeFloat:
 		result = make([]byte, 4)
@@ -97,6 +111,15 @@ func (d *dataTransform) ByteToValue(list *domain.DeviceList, variableList *domai
 	vType := &valueType{input: result}
 	byteOrder := domain.ByteOrderABCD
 	dataType := variableList.DataType
+	bitSize := 64
+	if dataType == domain.VarDataTypeString {
+		text, err := byteToString(variableList, result)
+		if err != nil {
+			return nil, err
+		}
+		vType.native = text
+		return vType, nil
+	}
 	length := 0
 	switch dataType {
 	case domain.VarDataTypeByte:
@@ -127,62 +150,52 @@ func (d *dataTransform) ByteToValue(list *domain.DeviceList, variableList *domai
 	if byteOrder == domain.ByteOrderCDAB || byteOrder == domain.ByteOrderBADC {
 		swap(result)
 	}
-	var value interface{}
 	switch dataType {
 	case domain.VarDataTypeBool:
-		vType.output = 0
-		if result[1] != 0 {
-			vType.output = 1
-		}
+		vType.native = result[1] != 0
 		return vType, nil
 	case domain.VarDataTypeByte:
-		value = float64(result[1])
+		vType.native = uint64(result[1])
 	case domain.VarDataTypeBit:
 		switch variableList.Param.RegType {
 		case domain.RegTypeSiemensI, domain.RegTypeSiemensQ, domain.RegTypeSiemensAQ, domain.RegTypeSiemensAI, domain.RegTypeSiemensSM,
 			domain.RegTypeSiemensV, domain.RegTypeSiemensDB, domain.RegTypeSiemensM, domain.RegTypeSiemensC, domain.RegTypeSiemensT:
-			vType.output = 0
-			if result[1] != 0 {
-				vType.output = 1
-			}
+			vType.native = result[1] != 0
 			return vType, nil
 		}
 		bitAddress := variableList.Param.BitAddr
 		r := int(result[0])*256 + int(result[1])
 		//log.Println(r, result[0], result[1])
-		v := (r >> bitAddress) & 0x01
 		//		value = (result[len(result)-1-(bitAddress/8)] >> (bitAddress % 8)) & 0x01
-		vType.output = float64(v)
-
+		vType.native = (r>>bitAddress)&0x01 != 0
 		return vType, nil
-	case domain.VarDataTypeUint16, domain.VarDataTypeInt16:
-		value = binary.BigEndian.Uint16(result)
-		if dataType == domain.VarDataTypeInt16 {
-			value = float64(int16(value.(uint16)))
-		} else {
-			value = float64(value.(uint16))
-		}
+	case domain.VarDataTypeUint16:
+		vType.native = uint64(binary.BigEndian.Uint16(result))
+	case domain.VarDataTypeInt16:
+		vType.native = int64(int16(binary.BigEndian.Uint16(result)))
 	case domain.VarDataTypeUint32, domain.VarDataTypeInt32:
+		var value uint32
 		if byteOrder == domain.ByteOrderABCD || byteOrder == domain.ByteOrderBADC {
 			value = binary.BigEndian.Uint32(result)
 		} else {
 			value = binary.LittleEndian.Uint32(result)
 		}
 		if dataType == domain.VarDataTypeInt32 {
-			value = float64(int32(value.(uint32)))
+			vType.native = int64(int32(value))
 		} else {
-			value = float64(value.(uint32))
+			vType.native = uint64(value)
 		}
 	case domain.VarDataTypeUint64, domain.VarDataTypeInt64:
+		var value uint64
 		if byteOrder == domain.ByteOrderABCD || byteOrder == domain.ByteOrderBADC {
 			value = binary.BigEndian.Uint64(result)
 		} else {
 			value = binary.LittleEndian.Uint64(result)
 		}
 		if dataType == domain.VarDataTypeInt64 {
-			value = float64(int64(value.(uint64)))
+			vType.native = int64(value)
 		} else {
-			value = float64(value.(uint64))
+			vType.native = value
 		}
 	case domain.VarDataTypeFloat:
 		var bits uint32
@@ -191,7 +204,8 @@ func (d *dataTransform) ByteToValue(list *domain.DeviceList, variableList *domai
 		} else {
 			bits = binary.LittleEndian.Uint32(result)
 		}
-		value = math.Float32frombits(bits)
+		vType.native = float64(math.Float32frombits(bits))
+		bitSize = 32
 	case domain.VarDataTypeDouble:
 		var bits uint64
 		if byteOrder == domain.ByteOrderABCD || byteOrder == domain.ByteOrderBADC {
@@ -199,17 +213,115 @@ func (d *dataTransform) ByteToValue(list *domain.DeviceList, variableList *domai
 		} else {
 			bits = binary.LittleEndian.Uint64(result)
 		}
-		value = math.Float64frombits(bits)
+		vType.native = math.Float64frombits(bits)
 	}
 
+	// integers stay exact unless they are scaled
 	if variableList.Modulus != 1 || variableList.Offset != 0 {
-		value = value.(float64)*variableList.Modulus + variableList.Offset
+		vType.native = vType.ToFloat64()*variableList.Modulus + variableList.Offset
+		bitSize = 64
+	}
+	if value, ok := vType.native.(float64); ok {
+		vType.native, _ = strconv.ParseFloat(strconv.FormatFloat(value, 'f', variableList.Decimal, bitSize), 64)
 	}
-	value, _ = strconv.ParseFloat(fmt.Sprintf("%."+strconv.Itoa(variableList.Decimal)+"f", value), 64)
-
-	vType.output = value.(float64)
 	return vType, nil
 }
+
+// toInteger negative values keep their two's complement in the unsigned integer
+func toInteger(value float64) uint64 {
+	if value < 0 {
+		return uint64(int64(value))
+	}
+	return uint64(value)
+}
+
+// StringSize bytes a string variable takes in the device
+func StringSize(variableList *domain.DataPointVariableList) int {
+	if variableList.Param.StrEncoding == domain.StringEncodingS7 {
+		return variableList.Param.StrLength + 2
+	}
+	return variableList.Param.StrLength
+}
+
+// byteToString the characters end at the first 0, the trailing spaces are padding
+func byteToString(variableList *domain.DataPointVariableList, result []byte) (string, error) {
+	size := StringSize(variableList)
+	if len(result) < size {
+		return "", fmt.Errorf("%s the length of the input data %d is less than the string length %d", variableList.Name, len(result), size)
+	}
+	data := make([]byte, len(result))
+	copy(data, result)
+	switch variableList.Param.StrEncoding {
+	case domain.StringEncodingASCIISwap:
+		if len(data)%2 != 0 {
+			data = append(data, 0)
+		}
+		swap(data)
+	case domain.StringEncodingS7:
+		actual := int(data[1])
+		if actual > size-2 {
+			actual = size - 2
+		}
+		data = data[2 : 2+actual]
+	}
+	if len(data) > variableList.Param.StrLength {
+		data = data[:variableList.Param.StrLength]
+	}
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		data = data[:end]
+	}
+	data = bytes.TrimRight(data, " ")
+	if variableList.Param.StrEncoding == domain.StringEncodingGBK {
+		decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(data)
+		if err != nil {
+			return "", fmt.Errorf("%s decode gbk failed: %w", variableList.Name, err)
+		}
+		data = decoded
+	}
+	return string(data), nil
+}
+
+// stringToByte the characters are cut or padded with 0 to the string length, numbers are written as their text
+func stringToByte(variableList *domain.DataPointVariableList, input interface{}) ([]byte, error) {
+	var text string
+	switch v := input.(type) {
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case uint64:
+		text = strconv.FormatUint(v, 10)
+	default:
+		return nil, fmt.Errorf("invalid value type %T of %s", input, variableList.Name)
+	}
+	data := []byte(text)
+	if variableList.Param.StrEncoding == domain.StringEncodingGBK {
+		encoded, err := simplifiedchinese.GBK.NewEncoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s encode gbk failed: %w", variableList.Name, err)
+		}
+		data = encoded
+	}
+	length := variableList.Param.StrLength
+	if len(data) > length {
+		data = data[:length]
+	}
+	characters := make([]byte, length)
+	copy(characters, data)
+	switch variableList.Param.StrEncoding {
+	case domain.StringEncodingASCIISwap:
+		if len(characters)%2 != 0 {
+			characters = append(characters, 0)
+		}
+		swap(characters)
+	case domain.StringEncodingS7:
+		characters = append([]byte{byte(length), byte(len(data))}, characters...)
+	}
+	return characters, nil
+}
+
 func swap(input []byte) {
 	if len(input)%2 != 0 {
 		return
@@ -0,0 +1,41 @@
+package dataTransform
+
+import (
+	"bytes"
+	"didaGatewayCenter/domain"
+	"encoding/hex"
+	"testing"
+)
+
+func TestValueToByte(t *testing.T) {
+	list := &domain.DeviceList{LongOrder: domain.ByteOrderABCD, LongLongOrder: domain.ByteOrderABCD}
+	tests := []struct {
+		name     string
+		dataType domain.DataType
+		modulus  float64
+		input    interface{}
+		want     string
+		wantErr  bool
+	}{
+		{"uint64 above 2^53", domain.VarDataTypeUint64, 1, uint64(1<<53 + 1), "0020000000000001", false},
+		{"max uint64", domain.VarDataTypeUint64, 1, uint64(1<<64 - 1), "FFFFFFFFFFFFFFFF", false},
+		{"int64 below -2^53", domain.VarDataTypeInt64, 1, int64(-1<<53 - 1), "FFDFFFFFFFFFFFFF", false},
+		{"negative int16", domain.VarDataTypeInt16, 1, float64(-5), "FFFB", false},
+		{"scaled int32", domain.VarDataTypeInt32, 0.1, int64(12), "00000078", false},
+		{"bool", domain.VarDataTypeBool, 1, int64(1), "0001", false},
+		{"invalid type", domain.VarDataTypeUint16, 1, true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variable := &domain.DataPointVariableList{Name: tt.name, DataType: tt.dataType, Modulus: tt.modulus}
+			got, err := NewDataTransformUsecase().ValueToByte(list, variable, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValueToByte() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want, _ := hex.DecodeString(tt.want)
+			if !tt.wantErr && !bytes.Equal(got, want) {
+				t.Fatalf("ValueToByte() = % X, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package dataTransform
+
+import (
+	"didaGatewayCenter/domain"
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// valueType native is bool, int64, uint64, float64 or string
+type valueType struct {
+	input  []byte
+	native interface{}
+}
+
+func (d *valueType) Kind() domain.ValueKind {
+	switch d.native.(type) {
+	case bool:
+		return domain.ValueKindBool
+	case int64:
+		return domain.ValueKindInt64
+	case uint64:
+		return domain.ValueKindUint64
+	case string:
+		return domain.ValueKindString
+	}
+	return domain.ValueKindFloat64
+}
+func (d *valueType) Value() interface{} {
+	return d.native
+}
+func (d *valueType) ToFloat64() float64 {
+	switch v := d.native.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		value, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return value
+	}
+	return 0
+}
+func (d *valueType) ToInt64() int64 {
+	switch v := d.native.(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	case string:
+		if value, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return value
+		}
+	}
+	return int64(d.ToFloat64())
+}
+func (d *valueType) ToUint64() uint64 {
+	switch v := d.native.(type) {
+	case int64:
+		return uint64(v)
+	case uint64:
+		return v
+	case string:
+		if value, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64); err == nil {
+			return value
+		}
+	}
+	return uint64(d.ToFloat64())
+}
+func (d *valueType) ToBool() bool {
+	if v, ok := d.native.(string); ok {
+		value, _ := strconv.ParseBool(strings.TrimSpace(v))
+		return value
+	}
+	return d.ToFloat64() != 0
+}
+func (d *valueType) ToString() string {
+	switch v := d.native.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return ""
+}
+func (d *valueType) ToBytes() []byte {
+	return d.input
+}
+func (d *valueType) OriginalValue() uint16 {
+	if len(d.input) < 2 {
+		return uint16(d.ToUint64())
+	}
+	return binary.BigEndian.Uint16(d.input)
+}
+
+// NewValue values which are not converted from the data of a device, native is bool, int64, uint64, float64 or string
+func NewValue(native interface{}) domain.IValueType {
+	return &valueType{native: native}
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type ConvertMode int
+
+const (
+	// ConvertModeModbusTcp Modbus TCP requests are converted to the RTU or ASCII frames of the serial port
+	ConvertModeModbusTcp ConvertMode = 0
+	// ConvertModeTransparent bytes are forwarded to the serial port unchanged
+	ConvertModeTransparent ConvertMode = 1
+)
+
+// IBusTransmitter drivers owning a serial bus send raw frames for other users between their own requests
+type IBusTransmitter interface {
+	Transmit(request []byte, timeout time.Duration) ([]byte, error)
+}
+
+type IConvertUsecase interface {
+	Serve(portConfig *DataPointPortConfig, transmitter IBusTransmitter)
+}
@@ -1,32 +1,38 @@
 package domain
 
-import (
-	"github.com/labstack/echo"
-	"time"
-)
+import "github.com/labstack/echo"
 
 type DataPoint struct {
 	PortConfig     *DataPointPortConfig
 	DeviceConfig   *DataPointDeviceConfig
 	VariableConfig []*DataPointVariableConfig
 	Driver         IDataPointDriverUsecase
+	DriverInfo     *DriverInfo
 }
-type AllDataPoints struct {
-	PortName     string      `json:"portName"`
-	DeviceName   string      `json:"deviceName"`
-	VariableName string      `json:"variableName"`
-	Value        interface{} `json:"value"`
-	Timestamp    time.Time   `json:"timestamp"`
-}
+
+// Quality OPC-style quality of a sampled value
+type Quality string
+
+const (
+	QualityGood Quality = "good"
+	// QualityBadCommFailure the device did not answer and no recent value is left
+	QualityBadCommFailure Quality = "bad-comm-failure"
+	// QualityBadConfig the variable cannot be read as configured
+	QualityBadConfig Quality = "bad-config"
+	// QualityUncertainStale the last read failed, Value is the last good value which is still recent
+	QualityUncertainStale Quality = "uncertain-stale"
+)
 
 type IDataPointUseCase interface {
 	Read(portName string, deviceName string, variableName string, isRealTime bool) (interface{}, error)
 	ReadById(id int64, isRealTime bool) (interface{}, error)
 	WriteById(id int64, value interface{}) (interface{}, error)
-	GetStore() []AllDataPoints
+	// PortsHealth the health of the connection of every sampled port
+	PortsHealth() []PortHealth
 	CycleSample()
 }
 type IDataPointHandler interface {
 	GetAllVariablesV1(ctx echo.Context) error
 	GetAllVariablesV2(ctx echo.Context) error
+	GetPortsStatus(ctx echo.Context) error
 }
@@ -1,9 +1,6 @@
 package domain
 
-import (
-	"github.com/labstack/echo"
-	"time"
-)
+import "github.com/labstack/echo"
 
 type IDataPointConfigUseCase interface {
 	GetPortConfigs() *Port
@@ -34,17 +31,60 @@ type PortParam struct {
 	DateBits int    `json:"DateBits"`
 	StopBit  int    `json:"StopBit"`
 
-	ConvertPort   int  `json:"ConvertPort"`
-	ConvertEnable bool `json:"ConvertEnable"`
+	ConvertPort   int         `json:"ConvertPort"`
+	ConvertEnable bool        `json:"ConvertEnable"`
+	ConvertMode   ConvertMode `json:"ConvertMode"`
 
 	FrameIntervalMs int `json:"FrameIntervalMs"`
 
 	IP         string `json:"IP"`
 	PortNumber int    `json:"PortNumber"`
+	// Modbus over the network, raw RTU frames are sent to serial-to-Ethernet converters in transparent mode
+	NetMode NetMode `json:"NetMode"`
 
 	RespTimeOutMs int `json:"RespTimeOutMs"`
 
+	// MaxReadGap Modbus block reads, unused registers or coils allowed between two variables of a block, a negative
+	// value reads every variable separately
+	MaxReadGap int `json:"MaxReadGap"`
+
+	// Siemens S7, the remote TSAP is computed from rack and slot unless TSAPs are given explicitly, rack is 0 and slot
+	// is the usual slot of the CPU of the plc family when they are not set
+	Rack    *int `json:"Rack"`
+	Slot    *int `json:"Slot"`
+	SrcTSAP int  `json:"SrcTSAP"`
+	DstTSAP int  `json:"DstTSAP"`
+
+	// Siemens Fetch/Write, PortNumber is the fetch connection, 0 means the port after PortNumber
+	WritePortNumber int `json:"WritePortNumber"`
+
+	// Mitsubishi Computer Link, dedicated protocol format 1 or 4, 0 means format 1
+	ComputerLinkFormat int `json:"ComputerLinkFormat"`
+
 	SampleIntervalS int `json:"SampleIntervalS"`
+	// the sample intervals of the scan classes, normal is SampleIntervalS when not set, fast is a fifth and slow ten
+	// times normal when not set
+	FastIntervalMs   int `json:"FastIntervalMs"`
+	NormalIntervalMs int `json:"NormalIntervalMs"`
+	SlowIntervalMs   int `json:"SlowIntervalMs"`
+	// MinIntervalMs the shortest interval of a scan class, 100 ms when not set
+	MinIntervalMs int `json:"MinIntervalMs"`
+
+	// Reconnect network ports, zero values use the defaults of the drivers
+	Reconnect ReconnectParam `json:"Reconnect"`
+}
+
+// ReconnectParam how a network port retries a lost connection, the delay between two attempts doubles from the
+// initial backoff up to the max backoff and resets once the port is connected
+type ReconnectParam struct {
+	InitialBackoffMs int `json:"InitialBackoffMs"`
+	MaxBackoffMs     int `json:"MaxBackoffMs"`
+	// JitterPercent part of the delay randomly added or removed so that the ports do not retry in step, negative
+	// disables the jitter
+	JitterPercent int `json:"JitterPercent"`
+	DialTimeoutMs int `json:"DialTimeoutMs"`
+	// MaxTimeouts consecutive timeouts after which the connection is reopened
+	MaxTimeouts int `json:"MaxTimeouts"`
 }
 
 type DeviceType int
@@ -81,6 +121,14 @@ const (
 	DeviceTypeMcBinaryQna1E     DeviceType = 3111
 )
 
+type NetMode int
+
+const (
+	NetModeTcp        NetMode = 0
+	NetModeRtuOverTcp NetMode = 1
+	NetModeRtuOverUdp NetMode = 2
+)
+
 type PortType int
 
 const (
@@ -99,11 +147,24 @@ type DeviceList struct {
 	DevName       string    `json:"DevName"`
 	OpcPath       string    `json:"OpcPath"`
 	DevAddr       int       `json:"DevAddr"`
+	MeterAddr     string    `json:"MeterAddr"` // 12-digit BCD address of DL/T 645 meters, empty for broadcast discovery
 	FloatOrder    ByteOrder `json:"FloatOrder"`
 	LongOrder     ByteOrder `json:"LongOrder"`
 	LongLongOrder ByteOrder `json:"LongLongOrder"`
 	DoubleOrder   ByteOrder `json:"DoubleOrder"`
+	ScanClass     ScanClass `json:"ScanClass"`
 }
+
+// ScanClass how often the variables are sampled, the variables take the class of their device unless they have one
+type ScanClass int
+
+const (
+	ScanClassDefault ScanClass = 0
+	ScanClassFast    ScanClass = 1
+	ScanClassNormal  ScanClass = 2
+	ScanClassSlow    ScanClass = 3
+)
+
 type ByteOrder int
 
 const (
@@ -156,20 +217,38 @@ type DataPointVariableList struct {
 	SignalType     int      `json:"SignalType"`
 	UpRangeValue   float64  `json:"UpRangeValue"`
 	DownRangeValue float64  `json:"DownRangeValue"`
-	Param          struct {
+	// ScanClass the class of the device when not set
+	ScanClass ScanClass `json:"ScanClass"`
+	Param     struct {
 		DBNum   int          `json:"DBNum"`
 		RegAddr int          `json:"RegAddr"`
 		BitAddr int          `json:"BitAddr"`
 		RegType RegisterType `json:"RegType"`
+		DI      string       `json:"DI"` // DL/T 645 data identifier in hex, 8 characters for 2007 and 4 for 1997
+		// Expression internal variables are computed from other variables when set, $12 is the variable whose Id is 12
+		Expression string `json:"Expression"`
+		// StrLength string variables, characters in bytes without the S7 STRING header
+		StrLength   int            `json:"StrLength"`
+		StrEncoding StringEncoding `json:"StrEncoding"`
 	} `json:"Param"`
 	Event struct {
 		EventName string `json:"EventName"`
 		MathType  int    `json:"MathType"`
 	} `json:"Event"`
-	Value     interface{}
-	Timestamp time.Time
 }
 
+type StringEncoding int
+
+const (
+	// StringEncodingASCII the characters in address order, padded with 0 or spaces
+	StringEncodingASCII StringEncoding = 0
+	// StringEncodingASCIISwap the two characters of every register are swapped, as many Modbus devices store them
+	StringEncodingASCIISwap StringEncoding = 1
+	// StringEncodingS7 S7 STRING, the maximum and the actual length come before the characters
+	StringEncodingS7  StringEncoding = 2
+	StringEncodingGBK StringEncoding = 3
+)
+
 type RegisterType int
 
 const (
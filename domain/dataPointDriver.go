@@ -7,10 +7,39 @@ type DataPointDriver struct {
 
 type IDataPointDriverUsecase interface {
 	Init(portInfo *DataPointPortConfig, transform IDataTransformUsecase)
-	Read(portInfo *DataPointPortConfig, deviceInfo *DeviceList, variableInfo *DataPointVariableList) IValueType
+	// Read the error is a *DriverError when the value is nil
+	Read(portInfo *DataPointPortConfig, deviceInfo *DeviceList, variableInfo *DataPointVariableList) (IValueType, error)
+	// Write nil only when the device has accepted the value
 	Write(portInfo *DataPointPortConfig, deviceInfo *DeviceList, variableInfo *DataPointVariableList, value interface{}) error
 }
 
+// IBlockReader drivers reading the variables of a device with as few requests as possible, the values and the errors
+// are returned in the order of the variables, the value is nil and the error set for the variables which failed to read
+type IBlockReader interface {
+	ReadBlock(portInfo *DataPointPortConfig, deviceInfo *DeviceList, variableList []*DataPointVariableList) ([]IValueType, []error)
+}
+
+// IHealthReporter drivers giving the health of their connection
+type IHealthReporter interface {
+	Health() PortHealth
+}
+
+// PortHealth the connection of a port, the response times are of the latest requests in milliseconds
+type PortHealth struct {
+	PortName            string     `json:"portName"`
+	DeviceType          DeviceType `json:"deviceType"`
+	Connected           bool       `json:"connected"`
+	LastConnectTime     time.Time  `json:"lastConnectTime"`
+	ReconnectCount      int        `json:"reconnectCount"`
+	ConsecutiveTimeouts int        `json:"consecutiveTimeouts"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastErrorTime       time.Time  `json:"lastErrorTime"`
+	Requests            uint64     `json:"requests"`
+	Responses           uint64     `json:"responses"`
+	AvgResponseMs       float64    `json:"avgResponseMs"`
+	P95ResponseMs       float64    `json:"p95ResponseMs"`
+}
+
 type Software interface {
 	ReadTimeout(t time.Duration) ([]byte, error)
 	WriteTimeout(writeData []byte, t time.Duration) error
@@ -5,9 +5,31 @@ type DataTransform struct {
 type ValueType struct {
 	input []byte
 }
+
+// ValueKind the native type of a sampled value
+type ValueKind int
+
+const (
+	ValueKindFloat64 ValueKind = 0
+	ValueKindBool    ValueKind = 1
+	ValueKindInt64   ValueKind = 2
+	ValueKindUint64  ValueKind = 3
+	ValueKindString  ValueKind = 4
+)
+
 type IValueType interface {
 	ToFloat64() float64
+	// ToString the text of string variables, the number formatted for the others
+	ToString() string
 	OriginalValue() uint16
+	Kind() ValueKind
+	ToBool() bool
+	ToInt64() int64
+	ToUint64() uint64
+	// ToBytes the data read from the device
+	ToBytes() []byte
+	// Value bool, int64, uint64, float64 or string as Kind tells
+	Value() interface{}
 }
 
 type IDataTransformUsecase interface {
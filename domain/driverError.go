@@ -0,0 +1,52 @@
+package domain
+
+import "fmt"
+
+// DriverErrorKind why a request of a driver failed
+type DriverErrorKind int
+
+const (
+	DriverErrorTimeout DriverErrorKind = 1
+	// DriverErrorConnectionLost the port is not connected or the connection broke during the request
+	DriverErrorConnectionLost DriverErrorKind = 2
+	// DriverErrorException the device answered with an exception or an error code, Code holds it
+	DriverErrorException DriverErrorKind = 3
+	// DriverErrorInvalidAddress the variable does not address anything the driver can read or write
+	DriverErrorInvalidAddress DriverErrorKind = 4
+	// DriverErrorInvalidResponse the response cannot be parsed or converted to the data type
+	DriverErrorInvalidResponse DriverErrorKind = 5
+)
+
+func (k DriverErrorKind) String() string {
+	switch k {
+	case DriverErrorTimeout:
+		return "timeout"
+	case DriverErrorConnectionLost:
+		return "connection lost"
+	case DriverErrorException:
+		return "exception"
+	case DriverErrorInvalidAddress:
+		return "invalid address"
+	case DriverErrorInvalidResponse:
+		return "invalid response"
+	}
+	return fmt.Sprintf("unknown(%d)", int(k))
+}
+
+// DriverError the errors the drivers return from Read and Write
+type DriverError struct {
+	Kind DriverErrorKind
+	Code int
+	Err  error
+}
+
+func (e *DriverError) Error() string {
+	if e.Kind == DriverErrorException {
+		return fmt.Sprintf("%s %d: %v", e.Kind, e.Code, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "github.com/labstack/echo"
+
+// DriverFactory creates the driver of a port, iDPU gives the driver access to the values of every variable
+type DriverFactory func(iLogU ILogUsecase, iDPU IDataPointUseCase) IDataPointDriverUsecase
+
+// DriverInfo a device type the running build supports, empty port types and register types are not checked
+type DriverInfo struct {
+	DeviceType    DeviceType     `json:"deviceType"`
+	Name          string         `json:"name"`
+	PortTypes     []PortType     `json:"portTypes"`
+	RegisterTypes []RegisterType `json:"registerTypes"`
+	DataTypes     []DataType     `json:"dataTypes"`
+	Factory       DriverFactory  `json:"-"`
+}
+
+// SupportsPortType whether the device type can be used on a port of portType
+func (d *DriverInfo) SupportsPortType(portType PortType) bool {
+	if len(d.PortTypes) == 0 {
+		return true
+	}
+	for _, single := range d.PortTypes {
+		if single == portType {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsRegisterType whether the driver can address regType, always true when the driver does not use them
+func (d *DriverInfo) SupportsRegisterType(regType RegisterType) bool {
+	if len(d.RegisterTypes) == 0 {
+		return true
+	}
+	for _, single := range d.RegisterTypes {
+		if single == regType {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsDataType whether the driver can read variables of dataType
+func (d *DriverInfo) SupportsDataType(dataType DataType) bool {
+	for _, single := range d.DataTypes {
+		if single == dataType {
+			return true
+		}
+	}
+	return false
+}
+
+type IDriverRegistry interface {
+	// Register adds the driver of a device type, a device type can be registered once
+	Register(info DriverInfo) error
+	// Lookup the driver of the device type, false when the build does not support it
+	Lookup(deviceType DeviceType) (*DriverInfo, bool)
+	// List the supported device types ordered by device type
+	List() []DriverInfo
+}
+
+type IDriverRegistryHandler interface {
+	GetDrivers(ctx echo.Context) error
+}
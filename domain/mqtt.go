@@ -32,6 +32,8 @@ const (
 	PTopicTypeAlinkEventPost       PTopicType = 4008
 	PTopicTypeHistoryPost          PTopicType = 4011
 	PTopicTypeRemoteUpdateResponse PTopicType = 4014
+	// PTopicTypePortStatus the connection health of every sampled port, published every UpIntervalS
+	PTopicTypePortStatus PTopicType = 4016
 )
 const (
 	STopicTypeReceive           STopicType = 4002
@@ -73,6 +75,8 @@ type PubTopicStruct struct {
 	PayloadType PayloadType `json:"PayloadType"`
 	UpIntervalS int         `json:"UpIntervalS"`
 	Valid       bool        `json:"Vaild"`
+	// PortName pass-through serial port of PTopicTypeSerialUpload, empty means every pass-through port
+	PortName string `json:"PortName"`
 }
 type SubTopicStruct struct {
 	Topic       string      `json:"Topic"`
@@ -80,6 +84,9 @@ type SubTopicStruct struct {
 	Type        STopicType  `json:"Type"`
 	PayloadType PayloadType `json:"PayloadType"`
 	Valid       bool        `json:"Vaild"`
+	// PortName pass-through serial port which PayloadTypeSerialUpload payloads are written to, empty means every
+	// pass-through port
+	PortName string `json:"PortName"`
 }
 
 type MqttConfigStruct struct {
@@ -22,6 +22,6 @@ type IMqttMessageUsecase interface {
 	GetTopicName() string
 	GetMqttName() string
 	GetPayloadName() string
-	GetPublishMsg(isRealTime bool) ([]byte, error)
+	GetPublishMsg() ([]byte, error)
 	GetCallBack() CallBack
 }
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// Sample the latest sampled value of a variable, a stored sample is never changed, every update stores a new one
+type Sample struct {
+	Id                int64       `json:"id"`
+	PortName          string      `json:"portName"`
+	DeviceName        string      `json:"deviceName"`
+	VariableName      string      `json:"variableName"`
+	Value             interface{} `json:"value"`
+	Timestamp         time.Time   `json:"timestamp"`
+	Quality           Quality     `json:"quality"`
+	LastGoodValue     interface{} `json:"lastGoodValue"`
+	LastGoodTimestamp time.Time   `json:"lastGoodTimestamp"`
+	SourceError       string      `json:"sourceError,omitempty"`
+}
+
+type IRealtimeStore interface {
+	// Register adds a variable, the id and the names of a variable are unique
+	Register(id int64, portName string, deviceName string, variableName string) error
+	// Update stores a copy of the sample of the variable changed by update, the updates of a variable are serialized
+	// and the subscribers are notified when the value or the quality changed
+	Update(id int64, update func(sample *Sample)) error
+	// Get the latest sample of the variable, it is shared and must not be changed
+	Get(id int64) (*Sample, bool)
+	// Lookup the latest sample of the variable by its names, it is shared and must not be changed
+	Lookup(portName string, deviceName string, variableName string) (*Sample, bool)
+	// Snapshot the latest samples of every variable in the order they were registered
+	Snapshot() []*Sample
+	// Subscribe the changed samples are sent to the returned channel, they are dropped while the channel is full,
+	// cancel closes the channel
+	Subscribe(size int) (samples <-chan *Sample, cancel func())
+}
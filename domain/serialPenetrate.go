@@ -0,0 +1,13 @@
+package domain
+
+// FrameListener receives every frame read from a pass-through serial port
+type FrameListener func(portName string, frame []byte)
+
+// ISerialPenetrateUsecase serial ports of DeviceTypeSerialPortPenetrate are not parsed, the received bytes are split
+// into frames by the idle time FrameIntervalMs and handed to the listeners
+type ISerialPenetrateUsecase interface {
+	// AddListener an empty portName listens on every pass-through port
+	AddListener(portName string, listener FrameListener)
+	// Write an empty portName writes to every pass-through port
+	Write(portName string, data []byte) error
+}
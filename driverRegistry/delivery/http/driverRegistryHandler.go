@@ -0,0 +1,25 @@
+package http
+
+import (
+	"didaGatewayCenter/domain"
+	"github.com/labstack/echo"
+	"net/http"
+)
+
+type driverRegistryHandler struct {
+	iDRU domain.IDriverRegistry
+}
+
+func NewDriverRegistryHandler(registry domain.IDriverRegistry) domain.IDriverRegistryHandler {
+	handler := &driverRegistryHandler{
+		iDRU: registry,
+	}
+	return handler
+}
+
+// GetDrivers the device types the running build supports, for the config tool
+func (d *driverRegistryHandler) GetDrivers(ctx echo.Context) error {
+	ret := make(map[string]interface{})
+	ret["ret"] = d.iDRU.List()
+	return ctx.JSON(http.StatusOK, ret)
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"didaGatewayCenter/dataPointDriver/dlt645"
+	"didaGatewayCenter/dataPointDriver/modbus"
+	"didaGatewayCenter/dataPointDriver/plc/mitsubishi"
+	"didaGatewayCenter/dataPointDriver/plc/omron"
+	"didaGatewayCenter/dataPointDriver/plc/siemens"
+	"didaGatewayCenter/dataPointDriver/virtual"
+	"didaGatewayCenter/domain"
+)
+
+var (
+	serialPort = []domain.PortType{domain.SerialType}
+	netPort    = []domain.PortType{domain.NetType}
+	// anyPort RTU frames also go over a network port when its NetMode is RTU over TCP or UDP
+	anyPort = []domain.PortType{domain.SerialType, domain.NetType}
+
+	numberDataTypes = []domain.DataType{domain.VarDataTypeUint16, domain.VarDataTypeUint32, domain.VarDataTypeUint64,
+		domain.VarDataTypeInt16, domain.VarDataTypeInt32, domain.VarDataTypeInt64, domain.VarDataTypeFloat, domain.VarDataTypeDouble}
+	wordDataTypes = append([]domain.DataType{domain.VarDataTypeBool, domain.VarDataTypeBit}, numberDataTypes...)
+	textDataTypes = append([]domain.DataType{domain.VarDataTypeString}, wordDataTypes...)
+	allDataTypes  = append([]domain.DataType{domain.VarDataTypeByte}, textDataTypes...)
+
+	modbusRegisterTypes = []domain.RegisterType{domain.RegTypeCoilStatusWithWriteMultiple, domain.RegTypeInputStatus,
+		domain.RegTypeHoldingRegisterWithWriteMultiple, domain.RegTypeInputRegister, domain.RegTypeCoilStatusWithWriteSingle,
+		domain.RegTypeHoldingRegisterWithWriteSingle}
+	mitsubishiRegisterTypes = []domain.RegisterType{domain.RegTypeMitsubishiXRegister, domain.RegTypeMitsubishiYRegister,
+		domain.RegTypeMitsubishiMRegister, domain.RegTypeMitsubishiSRegister, domain.RegTypeMitsubishiTRegister,
+		domain.RegTypeMitsubishiCRegister, domain.RegTypeMitsubishiDRegister, domain.RegTypeMitsubishiTVRegister,
+		domain.RegTypeMitsubishiCVRegister}
+	s7RegisterTypes = []domain.RegisterType{domain.RegTypeSiemensI, domain.RegTypeSiemensQ, domain.RegTypeSiemensM,
+		domain.RegTypeSiemensV, domain.RegTypeSiemensSM, domain.RegTypeSiemensAI, domain.RegTypeSiemensAQ,
+		domain.RegTypeSiemensT, domain.RegTypeSiemensC, domain.RegTypeSiemensDB}
+	// PPI addresses V as DB1, the other data blocks do not exist on S7-200
+	ppiRegisterTypes = []domain.RegisterType{domain.RegTypeSiemensI, domain.RegTypeSiemensQ, domain.RegTypeSiemensM,
+		domain.RegTypeSiemensV, domain.RegTypeSiemensSM, domain.RegTypeSiemensAI, domain.RegTypeSiemensAQ,
+		domain.RegTypeSiemensT, domain.RegTypeSiemensC}
+	fetchWriteRegisterTypes = []domain.RegisterType{domain.RegTypeSiemensDB, domain.RegTypeSiemensM,
+		domain.RegTypeSiemensI, domain.RegTypeSiemensQ}
+	omronRegisterTypes = []domain.RegisterType{domain.RegTypeOmronCIORegister, domain.RegTypeOmronLRegister,
+		domain.RegTypeOmronHRegister, domain.RegTypeOmronARegister, domain.RegTypeOmronDMRegister,
+		domain.RegTypeOmronEMRegister, domain.RegTypeOmronTSRegister, domain.RegTypeOmronCSRegister,
+		domain.RegTypeOmronTVRegister, domain.RegTypeOmronCVRegister, domain.RegTypeOmronWARegister}
+)
+
+func modbusDriver(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+	return modbus.NewModbusUsecase(iLogU)
+}
+
+func s7Driver(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+	return siemens.NewSiemensDriver(iLogU)
+}
+
+func mitsubishiDriver(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+	return mitsubishi.NewMitsubishiUsecaseDriver(iLogU)
+}
+
+func omronDriver(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+	return omron.NewOmronDriver(iLogU)
+}
+
+// virtualDriver expressions read the sampled values, not the devices
+func virtualDriver(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+	return virtual.NewVirtualDriver(iLogU, func(id int64) (interface{}, error) {
+		return iDPU.ReadById(id, false)
+	})
+}
+
+// builtinDrivers a new protocol is added to the gateway by adding its device types here
+func builtinDrivers() []domain.DriverInfo {
+	return []domain.DriverInfo{
+		{DeviceType: domain.DeviceTypeModbusRTU, Name: "Modbus RTU", PortTypes: anyPort,
+			RegisterTypes: modbusRegisterTypes, DataTypes: textDataTypes, Factory: modbusDriver},
+		{DeviceType: domain.DeviceTypeModbusASCII, Name: "Modbus ASCII", PortTypes: serialPort,
+			RegisterTypes: modbusRegisterTypes, DataTypes: textDataTypes, Factory: modbusDriver},
+		{DeviceType: domain.DeviceTypeModbusTCP, Name: "Modbus TCP", PortTypes: netPort,
+			RegisterTypes: modbusRegisterTypes, DataTypes: textDataTypes, Factory: modbusDriver},
+
+		{DeviceType: domain.DeviceTypeSiemensS200Smart, Name: "Siemens S7-200 SMART", PortTypes: netPort,
+			RegisterTypes: s7RegisterTypes, DataTypes: allDataTypes, Factory: s7Driver},
+		{DeviceType: domain.DeviceTypeSiemensS300, Name: "Siemens S7-300", PortTypes: netPort,
+			RegisterTypes: s7RegisterTypes, DataTypes: allDataTypes, Factory: s7Driver},
+		{DeviceType: domain.DeviceTypeSiemensS400, Name: "Siemens S7-400", PortTypes: netPort,
+			RegisterTypes: s7RegisterTypes, DataTypes: allDataTypes, Factory: s7Driver},
+		{DeviceType: domain.DeviceTypeSiemensS1200, Name: "Siemens S7-1200", PortTypes: netPort,
+			RegisterTypes: s7RegisterTypes, DataTypes: allDataTypes, Factory: s7Driver},
+		{DeviceType: domain.DeviceTypeSiemensS1500, Name: "Siemens S7-1500", PortTypes: netPort,
+			RegisterTypes: s7RegisterTypes, DataTypes: allDataTypes, Factory: s7Driver},
+		{DeviceType: domain.DeviceTypeS7200PPI, Name: "Siemens S7-200 PPI", PortTypes: serialPort,
+			RegisterTypes: ppiRegisterTypes, DataTypes: allDataTypes,
+			Factory: func(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+				return siemens.NewSiemensPPIDriver(iLogU)
+			}},
+		{DeviceType: domain.DeviceTypeSiemensFetchWrite, Name: "Siemens Fetch/Write", PortTypes: netPort,
+			RegisterTypes: fetchWriteRegisterTypes, DataTypes: append([]domain.DataType{domain.VarDataTypeByte}, wordDataTypes...),
+			Factory: func(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+				return siemens.NewSiemensFetchWriteDriver(iLogU)
+			}},
+
+		{DeviceType: domain.DeviceTypeMitsubishiProgramPort, Name: "Mitsubishi FX Programming Port", PortTypes: serialPort,
+			RegisterTypes: mitsubishiRegisterTypes, DataTypes: textDataTypes,
+			Factory: mitsubishiDriver},
+		{DeviceType: domain.DeviceTypeMitsubishiComputerLink, Name: "Mitsubishi Computer Link", PortTypes: serialPort,
+			RegisterTypes: mitsubishiRegisterTypes, DataTypes: textDataTypes,
+			Factory: mitsubishiDriver},
+		{DeviceType: domain.DeviceTypeMCBinaryQna3E, Name: "Mitsubishi MC 3E Binary", PortTypes: netPort,
+			RegisterTypes: mitsubishiRegisterTypes, DataTypes: textDataTypes,
+			Factory: mitsubishiDriver},
+		{DeviceType: domain.DeviceTypeMCAsciiQna3E, Name: "Mitsubishi MC 3E ASCII", PortTypes: netPort,
+			RegisterTypes: mitsubishiRegisterTypes, DataTypes: textDataTypes,
+			Factory: mitsubishiDriver},
+		{DeviceType: domain.DeviceTypeMcBinaryQna1E, Name: "Mitsubishi MC 1E Binary", PortTypes: netPort,
+			RegisterTypes: mitsubishiRegisterTypes, DataTypes: textDataTypes,
+			Factory: mitsubishiDriver},
+
+		{DeviceType: domain.DeviceTypeHostLinkFinsTcp, Name: "Omron FINS/TCP", PortTypes: netPort,
+			RegisterTypes: omronRegisterTypes, DataTypes: wordDataTypes, Factory: omronDriver},
+		{DeviceType: domain.DeviceTypeHostLinkCMode, Name: "Omron Host Link C-mode", PortTypes: serialPort,
+			RegisterTypes: omronRegisterTypes, DataTypes: wordDataTypes, Factory: omronDriver},
+		{DeviceType: domain.DeviceTypeHostLinkFins1, Name: "Omron Host Link FINS", PortTypes: serialPort,
+			RegisterTypes: omronRegisterTypes, DataTypes: wordDataTypes, Factory: omronDriver},
+		{DeviceType: domain.DeviceTypeHostLinkFins2, Name: "Omron Host Link FINS 2", PortTypes: serialPort,
+			RegisterTypes: omronRegisterTypes, DataTypes: wordDataTypes, Factory: omronDriver},
+
+		{DeviceType: domain.DeviceTypeDTL645, Name: "DL/T 645", PortTypes: serialPort, DataTypes: numberDataTypes,
+			Factory: func(iLogU domain.ILogUsecase, iDPU domain.IDataPointUseCase) domain.IDataPointDriverUsecase {
+				return dlt645.NewDlt645Driver(iLogU)
+			}},
+
+		{DeviceType: domain.DeviceTypeInternal, Name: "Internal",
+			DataTypes: allDataTypes, Factory: virtualDriver},
+		{DeviceType: domain.DeviceTypeInternal1, Name: "Internal 1",
+			DataTypes: allDataTypes, Factory: virtualDriver},
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"didaGatewayCenter/domain"
+	"fmt"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+)
+
+type driverRegistry struct {
+	drivers map[domain.DeviceType]domain.DriverInfo
+	lock    sync.RWMutex
+}
+
+func (d *driverRegistry) Register(info domain.DriverInfo) error {
+	if info.Factory == nil {
+		return fmt.Errorf("driver of device type %d has no factory", info.DeviceType)
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if registered, ok := d.drivers[info.DeviceType]; ok {
+		return fmt.Errorf("device type %d is already registered by %s", info.DeviceType, registered.Name)
+	}
+	d.drivers[info.DeviceType] = info
+	return nil
+}
+
+func (d *driverRegistry) Lookup(deviceType domain.DeviceType) (*domain.DriverInfo, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	info, ok := d.drivers[deviceType]
+	if !ok {
+		return nil, false
+	}
+	return &info, true
+}
+
+func (d *driverRegistry) List() []domain.DriverInfo {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	ret := make([]domain.DriverInfo, 0, len(d.drivers))
+	for _, info := range d.drivers {
+		ret = append(ret, info)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].DeviceType < ret[j].DeviceType
+	})
+	return ret
+}
+
+// NewDriverRegistry the registry starts with the drivers built into the gateway
+func NewDriverRegistry(iLogU domain.ILogUsecase) domain.IDriverRegistry {
+	d := &driverRegistry{
+		drivers: make(map[domain.DeviceType]domain.DriverInfo),
+	}
+	for _, info := range builtinDrivers() {
+		if err := d.Register(info); err != nil {
+			iLogU.GetLogger().Error("cannot register driver", zap.String("name", info.Name), zap.Error(err))
+		}
+	}
+	return d
+}
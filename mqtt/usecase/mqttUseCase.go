@@ -17,8 +17,10 @@ import (
 type Mqtt struct {
 	iSU   domain.ISystemUseCase
 	iDPU  domain.IDataPointUseCase
+	iRSU  domain.IRealtimeStore
 	iACU  domain.IAppConfigUseCase
 	iLogU domain.ILogUsecase
+	iSPU  domain.ISerialPenetrateUsecase
 	nMqtt []*NewMqtt
 }
 
@@ -30,21 +32,36 @@ type NewMqtt struct {
 	iMMUS      []domain.IMqttMessageUsecase
 }
 
+// writeChanges the changed samples a write through mqtt can cause before they are published
+const writeChanges = 64
+
 func (m *Mqtt) PublishDataPoints() {
 	for _, singleMqtt := range m.nMqtt {
 		for _, singleIMMU := range singleMqtt.iPMMU {
 			topicName := singleIMMU.GetTopicName()
-			msg, _ := singleIMMU.GetPublishMsg(true)
+			msg, _ := singleIMMU.GetPublishMsg()
 			_ = singleMqtt.client.Publish(topicName, 0, false, msg)
 		}
 	}
 }
-func NewMqttUseCase(iACU domain.IAppConfigUseCase, iLog domain.ILogUsecase, useCase domain.ISystemUseCase, iDPU domain.IDataPointUseCase) domain.IMqttUseCase {
+
+// publishChanges the data points are published again when the store reported changed samples, the values written
+// are read back into the store before the write returns
+func (m *Mqtt) publishChanges(samples <-chan *domain.Sample) {
+	if _, ok := <-samples; !ok {
+		return
+	}
+	m.PublishDataPoints()
+}
+func NewMqttUseCase(iACU domain.IAppConfigUseCase, iLog domain.ILogUsecase, useCase domain.ISystemUseCase, iDPU domain.IDataPointUseCase,
+	iSPU domain.ISerialPenetrateUsecase, iRSU domain.IRealtimeStore) domain.IMqttUseCase {
 	m := &Mqtt{
 		iSU:   useCase,
 		iDPU:  iDPU,
+		iRSU:  iRSU,
 		iACU:  iACU,
 		iLogU: iLog,
+		iSPU:  iSPU,
 	}
 	mqttConfig := domain.MqttConfigStruct{}
 	logger := iLog.GetLogger()
@@ -141,7 +158,7 @@ func (n *NewMqtt) Connect() {
 		switch singlePublishTopic.Type {
 		case domain.PTopicTypeUpload, domain.PTopicTypeAlinkPropertyPost:
 			payloadName := fmt.Sprintf("P%d.json", singlePublishTopic.PayloadType)
-			p, err := usecase2.NewMqttMessageUsecase(mqttName, singlePublishTopic.Topic, payloadName, n.Parent.iACU, n.Parent.iDPU)
+			p, err := usecase2.NewMqttMessageUsecase(mqttName, singlePublishTopic.Topic, payloadName, n.Parent.iACU, n.Parent.iDPU, n.Parent.iRSU)
 			if err != nil {
 				n.Parent.iLogU.GetLogger().Warn("failed to set publish message format", zap.String("mqttName", mqttName),
 					zap.String("topic", singlePublishTopic.Topic), zap.Error(err))
@@ -151,7 +168,10 @@ func (n *NewMqtt) Connect() {
 			}
 			n.iPMMU = append(n.iPMMU, p)
 			go n.publishMsg(singlePublishTopic.Topic, byte(singlePublishTopic.QoS), p, time.Duration(singlePublishTopic.UpIntervalS)*time.Second)
-
+		case domain.PTopicTypeSerialUpload:
+			n.serialUpload(singlePublishTopic)
+		case domain.PTopicTypePortStatus:
+			go n.publishPortStatus(singlePublishTopic)
 		}
 	}
 }
@@ -159,7 +179,7 @@ func (n *NewMqtt) Connect() {
 func (n *NewMqtt) publishMsg(topic string, qos byte, publishUsecase domain.IMqttMessageUsecase, interval time.Duration) {
 	client := n.client
 	iLogU := n.Parent.iLogU
-	m2, _ := publishUsecase.GetPublishMsg(false)
+	m2, _ := publishUsecase.GetPublishMsg()
 	if token := client.Publish(topic, qos, false, m2); token.Wait() {
 		if token.Error() != nil {
 			iLogU.GetLogger().Warn("publish dataPoints message error", zap.String("mqttName", publishUsecase.GetMqttName()),
@@ -184,7 +204,7 @@ func (n *NewMqtt) publishMsg(topic string, qos byte, publishUsecase domain.IMqtt
 		if !client.IsConnectionOpen() {
 			continue
 		}
-		p, _ := publishUsecase.GetPublishMsg(false)
+		p, _ := publishUsecase.GetPublishMsg()
 		if token := client.Publish(topic, qos, false, p); token.Wait() {
 			if token.Error() != nil {
 				iLogU.GetLogger().Warn("publish message failed", zap.String("topic", topic),
@@ -221,8 +241,12 @@ func (n *NewMqtt) subscribe() {
 		qos := singSubTopic.QoS
 		switch singSubTopic.Type {
 		case domain.STopicTypeReceive:
+			if payloadType == domain.PayloadTypeSerialUpload {
+				n.subscribeSerial(singSubTopic)
+				continue
+			}
 			payloadName := fmt.Sprintf("S%d.json", payloadType)
-			s, err := usecase2.NewMqttMessageUsecase(mqttName, topicName, payloadName, n.Parent.iACU, n.Parent.iDPU)
+			s, err := usecase2.NewMqttMessageUsecase(mqttName, topicName, payloadName, n.Parent.iACU, n.Parent.iDPU, n.Parent.iRSU)
 			if err != nil {
 				n.Parent.iLogU.GetLogger().Error("set subscribe message format failed", zap.String("mqttName", mqttName),
 					zap.String("topic", topicName), zap.String("payloadName", payloadName), zap.Error(err))
@@ -232,8 +256,10 @@ func (n *NewMqtt) subscribe() {
 					zap.String("topic", topicName), zap.String("payloadName", payloadName))
 			}
 			token := n.client.Subscribe(topicName, byte(qos), func(client mqtt.Client, message mqtt.Message) {
+				samples, cancel := n.Parent.iRSU.Subscribe(writeChanges)
 				s.GetCallBack().DataPointSet(client, message)
-				go n.Parent.PublishDataPoints()
+				cancel()
+				go n.Parent.publishChanges(samples)
 			})
 			if token.Wait() {
 				if err := token.Error(); err != nil {
@@ -244,6 +270,41 @@ func (n *NewMqtt) subscribe() {
 		}
 	}
 }
+
+// serialUpload publishes the frames received on pass-through serial ports as they are
+func (n *NewMqtt) serialUpload(topic domain.PubTopicStruct) {
+	mqttName := n.mqttConfig.MQTTName
+	n.Parent.iSPU.AddListener(topic.PortName, func(portName string, frame []byte) {
+		if !n.client.IsConnectionOpen() {
+			return
+		}
+		if token := n.client.Publish(topic.Topic, byte(topic.QoS), false, frame); token.Wait() && token.Error() != nil {
+			n.Parent.iLogU.GetLogger().Warn("publish serial frame failed", zap.String("mqttName", mqttName),
+				zap.String("topic", topic.Topic), zap.String("portName", portName), zap.Error(token.Error()))
+		}
+	})
+	n.Parent.iLogU.GetLogger().Info("serial upload topic is set", zap.String("mqttName", mqttName),
+		zap.String("topic", topic.Topic), zap.String("portName", topic.PortName))
+}
+
+// subscribeSerial payloads of the topic are written to pass-through serial ports as they are
+func (n *NewMqtt) subscribeSerial(topic domain.SubTopicStruct) {
+	mqttName := n.mqttConfig.MQTTName
+	token := n.client.Subscribe(topic.Topic, byte(topic.QoS), func(client mqtt.Client, message mqtt.Message) {
+		if err := n.Parent.iSPU.Write(topic.PortName, message.Payload()); err != nil {
+			n.Parent.iLogU.GetLogger().Warn("write serial frame failed", zap.String("mqttName", mqttName),
+				zap.String("topic", topic.Topic), zap.String("portName", topic.PortName), zap.Error(err))
+		}
+	})
+	if token.Wait() {
+		if err := token.Error(); err != nil {
+			n.Parent.iLogU.GetLogger().Error("subscribe topic failed", zap.String("mqttName", mqttName), zap.String("topic", topic.Topic), zap.Error(err))
+			return
+		}
+		n.Parent.iLogU.GetLogger().Info("subscribe topic succeeded", zap.String("mqttName", mqttName), zap.String("topic", topic.Topic))
+	}
+}
+
 func (n *NewMqtt) onConnectLost(client mqtt.Client, err error) {
 	opt := client.OptionsReader()
 	clientId := opt.ClientID()
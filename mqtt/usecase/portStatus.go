@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"didaGatewayCenter/domain"
+	"encoding/json"
+	"go.uber.org/zap"
+	"time"
+)
+
+// defaultPortStatusInterval the interval of the port status topic when UpIntervalS is not set
+const defaultPortStatusInterval = time.Second * 10
+
+// publishPortStatus publishes the connection health of every sampled port until the gateway exits
+func (n *NewMqtt) publishPortStatus(topic domain.PubTopicStruct) {
+	mqttName := n.mqttConfig.MQTTName
+	interval := time.Duration(topic.UpIntervalS) * time.Second
+	if interval <= 0 {
+		interval = defaultPortStatusInterval
+	}
+	n.Parent.iLogU.GetLogger().Info("port status topic is set", zap.String("mqttName", mqttName),
+		zap.String("topic", topic.Topic), zap.Duration("interval", interval))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !n.client.IsConnectionOpen() {
+			continue
+		}
+		payload, err := json.Marshal(map[string]interface{}{
+			"timestamp": time.Now(),
+			"ports":     n.Parent.iDPU.PortsHealth(),
+		})
+		if err != nil {
+			n.Parent.iLogU.GetLogger().Warn("marshal port status failed", zap.String("mqttName", mqttName), zap.Error(err))
+			continue
+		}
+		if token := n.client.Publish(topic.Topic, byte(topic.QoS), false, payload); token.Wait() && token.Error() != nil {
+			n.Parent.iLogU.GetLogger().Warn("publish port status failed", zap.String("mqttName", mqttName),
+				zap.String("topic", topic.Topic), zap.Error(token.Error()))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"bytes"
 	"didaGatewayCenter/domain"
 	"encoding/json"
 	"errors"
@@ -21,6 +22,7 @@ var regexp1 map[domain.RegexpPatternType]*regexp.Regexp
 
 type mqttMessageUsecase struct {
 	iDPU    domain.IDataPointUseCase
+	store   domain.IRealtimeStore
 	message domain.Message
 }
 
@@ -28,7 +30,8 @@ func (m *mqttMessageUsecase) GetTopicName() string {
 	return m.message.TopicName
 }
 
-func NewMqttMessageUsecase(mqttName string, topicName string, payloadName string, iACU domain.IAppConfigUseCase, iDPU domain.IDataPointUseCase) (domain.IMqttMessageUsecase, error) {
+func NewMqttMessageUsecase(mqttName string, topicName string, payloadName string, iACU domain.IAppConfigUseCase, iDPU domain.IDataPointUseCase,
+	store domain.IRealtimeStore) (domain.IMqttMessageUsecase, error) {
 
 	if regexp1 == nil {
 		regexp1 = make(map[domain.RegexpPatternType]*regexp.Regexp)
@@ -49,7 +52,8 @@ func NewMqttMessageUsecase(mqttName string, topicName string, payloadName string
 			TopicName:   topicName,
 			PayloadName: payloadName,
 		},
-		iDPU: iDPU,
+		iDPU:  iDPU,
+		store: store,
 	}
 
 	dir := iACU.GetAppMqttConfig().MessageConfig.Dir
@@ -87,6 +91,9 @@ func (m *mqttMessageUsecase) DataPointSet(client mqtt.Client, message mqtt.Messa
 	template := make(map[string]interface{})
 	payloadMap := make(map[string]interface{})
 	json.Unmarshal(msgTemplate, &template)
-	json.Unmarshal(msgPayload, &payloadMap)
+	// the numbers are kept as text, integers above 2^53 would be rounded as float64
+	decoder := json.NewDecoder(bytes.NewReader(msgPayload))
+	decoder.UseNumber()
+	decoder.Decode(&payloadMap)
 	m.parseReceive(template, payloadMap)
 }
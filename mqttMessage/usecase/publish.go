@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"didaGatewayCenter/domain"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -8,25 +9,25 @@ import (
 	"time"
 )
 
-func (m *mqttMessageUsecase) GetPublishMsg(isRealTime bool) ([]byte, error) {
+func (m *mqttMessageUsecase) GetPublishMsg() ([]byte, error) {
 
 	m.message.Lock.Lock()
 	defer m.message.Lock.Unlock()
 
 	_ = json.Unmarshal(m.message.MsgTemplate, &m.message.Msg)
 
-	m.generateMsgFormatObject(m.message.Msg, isRealTime)
+	m.generateMsgFormatObject(m.message.Msg)
 
 	t, _ := json.Marshal(m.message.Msg)
 	return t, nil
 }
-func (m *mqttMessageUsecase) generateMsgFormatObject(v map[string]interface{}, isRealTime bool) {
+func (m *mqttMessageUsecase) generateMsgFormatObject(v map[string]interface{}) {
 	for key, value := range v {
 		switch value.(type) {
 		case map[string]interface{}:
-			m.generateMsgFormatObject(value.(map[string]interface{}), isRealTime)
+			m.generateMsgFormatObject(value.(map[string]interface{}))
 		case []interface{}:
-			m.generateMsgFormatArray(value.([]interface{}), isRealTime)
+			m.generateMsgFormatArray(value.([]interface{}))
 		case string:
 			if strings.HasPrefix(value.(string), "${") {
 				if strings.Contains(value.(string), "${timestampMs") {
@@ -55,29 +56,98 @@ func (m *mqttMessageUsecase) generateMsgFormatObject(v map[string]interface{}, i
 					variableName := aaa[1]
 					id1, _ := strconv.ParseInt(variableName, 10, 64)
 					variableValueType := aaa[2]
-					variableValue, _ := m.iDPU.ReadById(id1, isRealTime)
+					sample, ok := m.store.Get(id1)
+					if variableValueType == "quality" {
+						var quality domain.Quality
+						if ok {
+							quality = sample.Quality
+						}
+						v[key] = quality
+						continue
+					}
+					var variableValue interface{}
+					if ok {
+						variableValue = sample.Value
+					}
 
 					if variableValue == nil {
 						v[key] = nil
+						continue
 					}
-					switch variableValueType {
-					case "float64":
-						v[key] = variableValue
-					case "string":
-						v[key] = fmt.Sprintf("%f", variableValue)
-					}
+					v[key] = formatVariable(variableValueType, variableValue)
 				}
 			}
 		}
 	}
 }
-func (m *mqttMessageUsecase) generateMsgFormatArray(value []interface{}, isRealTime bool) {
+
+// formatVariable value keeps the native type of the variable, float64 and string convert it like the templates
+// always did, int64, uint64 and bool convert the numbers
+func formatVariable(valueType string, value interface{}) interface{} {
+	switch valueType {
+	case "value":
+		return value
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v
+		case float64:
+			return fmt.Sprintf("%f", v)
+		}
+		return fmt.Sprint(value)
+	}
+	var number float64
+	switch v := value.(type) {
+	case bool:
+		if valueType == "bool" {
+			return v
+		}
+		if v {
+			number = 1
+		}
+	case int64:
+		switch valueType {
+		case "int64":
+			return v
+		case "uint64":
+			return uint64(v)
+		}
+		number = float64(v)
+	case uint64:
+		switch valueType {
+		case "int64":
+			return int64(v)
+		case "uint64":
+			return v
+		}
+		number = float64(v)
+	case float64:
+		number = v
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return v
+		}
+		number = parsed
+	}
+	switch valueType {
+	case "int64":
+		return int64(number)
+	case "uint64":
+		return uint64(number)
+	case "bool":
+		return number != 0
+	}
+	return number
+}
+
+func (m *mqttMessageUsecase) generateMsgFormatArray(value []interface{}) {
 	for _, v := range value {
 		switch v.(type) {
 		case []interface{}:
-			m.generateMsgFormatArray(v.([]interface{}), isRealTime)
+			m.generateMsgFormatArray(v.([]interface{}))
 		case map[string]interface{}:
-			m.generateMsgFormatObject(v.(map[string]interface{}), isRealTime)
+			m.generateMsgFormatObject(v.(map[string]interface{}))
 		}
 	}
 }
@@ -26,7 +26,7 @@ func (m *mqttMessageUsecase) parseReceive(template map[string]interface{}, paylo
 			tempValue := value.(string)
 			if strings.HasPrefix(tempValue, "${") {
 				if strings.Contains(tempValue, "${variable}.") {
-					variableValue := payload[key].(float64)
+					variableValue := payload[key]
 					idString := regexp.MustCompile(`^\$\{variable}\.\$\{(.*)}\.(\w+)$`).FindStringSubmatch(tempValue)[1]
 					id, _ := strconv.ParseInt(idString, 10, 64)
 					_, err := m.iDPU.WriteById(id, variableValue)
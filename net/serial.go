@@ -2,6 +2,7 @@ package net
 
 import (
 	"didaGatewayCenter/domain"
+	"errors"
 	"github.com/goburrow/serial"
 	"time"
 )
@@ -10,6 +11,8 @@ type serial1 struct {
 	fd serial.Port
 }
 
+// ReadTimeout waits up to t for the first byte, the frame ends at the first gap longer than the timeout of the port,
+// serial.ErrTimeout when the device did not answer
 func (s *serial1) ReadTimeout(t time.Duration) ([]byte, error) {
 	var buffer []byte
 	nowTime := time.Now()
@@ -19,16 +22,22 @@ func (s *serial1) ReadTimeout(t time.Duration) ([]byte, error) {
 	for {
 		temp := make([]byte, 1)
 		n, err := s.fd.Read(temp)
+		if err == nil && n == 0 {
+			err = serial.ErrTimeout
+		}
 		if err != nil {
-			if len(buffer) == 0 {
-				if time.Now().After(nowTime.Add(t)) {
-					return nil, err
-				}
+			if len(buffer) > 0 {
+				return buffer, nil
 			}
-			return buffer, nil
-		} else {
-			buffer = append(buffer, temp[:n]...)
+			if !errors.Is(err, serial.ErrTimeout) {
+				return nil, err
+			}
+			if time.Now().After(nowTime.Add(t)) {
+				return nil, serial.ErrTimeout
+			}
+			continue
 		}
+		buffer = append(buffer, temp[:n]...)
 	}
 }
 
@@ -63,6 +72,10 @@ func (s *serial1) WriteReadTimeout(writeData []byte, t time.Duration) ([]byte, e
 	return s.ReadTimeout(t)
 }
 
+func (s *serial1) Close() error {
+	return s.fd.Close()
+}
+
 func New(fd serial.Port) domain.Software {
 	return &serial1{
 		fd: fd,
@@ -2,6 +2,8 @@ package net
 
 import (
 	"didaGatewayCenter/domain"
+	"fmt"
+	"io"
 	"net"
 	"time"
 )
@@ -55,8 +57,53 @@ func (netTcp *Tcp) WriteReadTimeout(writeData []byte, t time.Duration) ([]byte,
 	}
 }
 
-func Dial(network string, address string) (domain.Software, error) {
-	conn, err := net.Dial(network, address)
+// WriteReadFrame writes writeData and reads exactly one response frame, frameLength gives the length of the whole frame
+// from its first headerLength bytes, the frame may arrive in several segments
+func (netTcp *Tcp) WriteReadFrame(writeData []byte, headerLength int, frameLength func(header []byte) (int, error), t time.Duration) ([]byte, error) {
+	if t <= 0 {
+		t = time.Duration(1) * time.Second
+	}
+	_, _ = netTcp.ReadTimeout(time.Millisecond * 10)
+	if err := netTcp.WriteTimeout(writeData, t); err != nil {
+		return nil, err
+	}
+	_ = netTcp.Conn.SetReadDeadline(time.Now().Add(t))
+	header := make([]byte, headerLength)
+	if _, err := io.ReadFull(netTcp.Conn, header); err != nil {
+		return nil, err
+	}
+	length, err := frameLength(header)
+	if err != nil {
+		return header, err
+	}
+	if length < headerLength {
+		return header, fmt.Errorf("frame length %d is shorter than the header", length)
+	}
+	frame := make([]byte, length)
+	copy(frame, header)
+	if _, err := io.ReadFull(netTcp.Conn, frame[headerLength:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (netTcp *Tcp) Close() error {
+	if netTcp.Conn == nil {
+		return nil
+	}
+	return netTcp.Conn.Close()
+}
+
+// Close closes the serial port or the network connection under conn, nil is ignored
+func Close(conn domain.Software) {
+	if closer, ok := conn.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// Dial connects to address, timeout limits the dial only, 0 means no limit
+func Dial(network string, address string, timeout time.Duration) (domain.Software, error) {
+	conn, err := net.DialTimeout(network, address, timeout)
 	return &Tcp{
 		Conn: conn,
 	}, err
@@ -0,0 +1,69 @@
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func frameLength(header []byte) (int, error) {
+	return int(binary.BigEndian.Uint16(header[2:4])), nil
+}
+
+// serve reads a request and answers with the segments, one write each
+func serve(conn net.Conn, segments ...[]byte) {
+	go func() {
+		request := make([]byte, 16)
+		if _, err := conn.Read(request); err != nil {
+			return
+		}
+		for _, segment := range segments {
+			if _, err := conn.Write(segment); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestWriteReadFrame(t *testing.T) {
+	// a frame of 300 bytes, longer than a single read of ReadTimeout and a multiple of 100
+	frame := make([]byte, 300)
+	copy(frame, []byte{0x03, 0x00, 0x01, 0x2C})
+	for index := 4; index < len(frame); index++ {
+		frame[index] = byte(index)
+	}
+	tests := []struct {
+		name     string
+		segments [][]byte
+		wantErr  error
+	}{
+		{"one segment", [][]byte{frame}, nil},
+		{"split header and body", [][]byte{frame[:2], frame[2:150], frame[150:]}, nil},
+		{"incomplete", [][]byte{frame[:200]}, os.ErrDeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			serve(server, tt.segments...)
+			got, err := (&Tcp{Conn: client}).WriteReadFrame([]byte{0x01}, 4, frameLength, 200*time.Millisecond)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WriteReadFrame() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WriteReadFrame() error = %v", err)
+			}
+			if !bytes.Equal(got, frame) {
+				t.Fatalf("WriteReadFrame() = %d bytes, want %d", len(got), len(frame))
+			}
+		})
+	}
+}
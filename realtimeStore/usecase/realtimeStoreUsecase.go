@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"didaGatewayCenter/domain"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// entry the writers of a variable hold lock, the readers load the sample without it
+type entry struct {
+	sample atomic.Pointer[domain.Sample]
+	lock   sync.Mutex
+}
+
+type realtimeStore struct {
+	entries     []*entry
+	byId        map[int64]*entry
+	byName      map[string]*entry
+	subscribers map[chan *domain.Sample]struct{}
+	lock        sync.RWMutex
+}
+
+func (r *realtimeStore) Register(id int64, portName string, deviceName string, variableName string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.byId[id]; ok {
+		return fmt.Errorf("variable id %d is already registered", id)
+	}
+	name := nameKey(portName, deviceName, variableName)
+	if _, ok := r.byName[name]; ok {
+		return fmt.Errorf("variable %s of device %s of port %s is already registered", variableName, deviceName, portName)
+	}
+	e := &entry{}
+	e.sample.Store(&domain.Sample{Id: id, PortName: portName, DeviceName: deviceName, VariableName: variableName})
+	r.entries = append(r.entries, e)
+	r.byId[id] = e
+	r.byName[name] = e
+	return nil
+}
+
+func (r *realtimeStore) Update(id int64, update func(sample *domain.Sample)) error {
+	r.lock.RLock()
+	e, ok := r.byId[id]
+	r.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("variable id %d is not registered", id)
+	}
+	e.lock.Lock()
+	previous := e.sample.Load()
+	sample := *previous
+	update(&sample)
+	// the identity of the variable is not changed by the updates
+	sample.Id, sample.PortName, sample.DeviceName, sample.VariableName = previous.Id, previous.PortName, previous.DeviceName, previous.VariableName
+	e.sample.Store(&sample)
+	if sample.Quality != previous.Quality || !reflect.DeepEqual(sample.Value, previous.Value) {
+		r.notify(&sample)
+	}
+	e.lock.Unlock()
+	return nil
+}
+
+func (r *realtimeStore) Get(id int64) (*domain.Sample, bool) {
+	r.lock.RLock()
+	e, ok := r.byId[id]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return e.sample.Load(), true
+}
+
+func (r *realtimeStore) Lookup(portName string, deviceName string, variableName string) (*domain.Sample, bool) {
+	r.lock.RLock()
+	e, ok := r.byName[nameKey(portName, deviceName, variableName)]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return e.sample.Load(), true
+}
+
+func (r *realtimeStore) Snapshot() []*domain.Sample {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	ret := make([]*domain.Sample, 0, len(r.entries))
+	for _, e := range r.entries {
+		ret = append(ret, e.sample.Load())
+	}
+	return ret
+}
+
+func (r *realtimeStore) Subscribe(size int) (<-chan *domain.Sample, func()) {
+	ch := make(chan *domain.Sample, size)
+	r.lock.Lock()
+	r.subscribers[ch] = struct{}{}
+	r.lock.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.lock.Lock()
+			delete(r.subscribers, ch)
+			r.lock.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// notify the subscribers are slower than the sampling, a full channel loses the sample rather than blocking the port
+func (r *realtimeStore) notify(sample *domain.Sample) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for ch := range r.subscribers {
+		select {
+		case ch <- sample:
+		default:
+		}
+	}
+}
+
+func nameKey(portName string, deviceName string, variableName string) string {
+	return portName + "\x00" + deviceName + "\x00" + variableName
+}
+
+func NewRealtimeStore() domain.IRealtimeStore {
+	return &realtimeStore{
+		byId:        make(map[int64]*entry),
+		byName:      make(map[string]*entry),
+		subscribers: make(map[chan *domain.Sample]struct{}),
+	}
+}
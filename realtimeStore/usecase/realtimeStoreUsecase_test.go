@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"didaGatewayCenter/domain"
+	"sync"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	store := NewRealtimeStore()
+	if err := store.Register(1, "port", "device", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Register(1, "port", "device", "b"); err == nil {
+		t.Fatal("duplicate id is registered")
+	}
+	if err := store.Register(2, "port", "device", "a"); err == nil {
+		t.Fatal("duplicate name is registered")
+	}
+	if err := store.Register(2, "port", "device2", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update(3, func(sample *domain.Sample) {}); err == nil {
+		t.Fatal("unknown id is updated")
+	}
+	sample, ok := store.Lookup("port", "device2", "a")
+	if !ok || sample.Id != 2 {
+		t.Fatalf("Lookup() = %v %v, want id 2", sample, ok)
+	}
+	snapshot := store.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Id != 1 || snapshot[1].Id != 2 {
+		t.Fatalf("Snapshot() is not in the order of registration")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	store := NewRealtimeStore()
+	_ = store.Register(1, "port", "device", "a")
+	before, _ := store.Get(1)
+	_ = store.Update(1, func(sample *domain.Sample) {
+		sample.Id = 5
+		sample.VariableName = "b"
+		sample.Value = float64(1)
+	})
+	after, _ := store.Get(1)
+	if before.Value != nil {
+		t.Fatal("a sample which was read is changed by an update")
+	}
+	if after.Id != 1 || after.VariableName != "a" || after.Value != float64(1) {
+		t.Fatalf("Get() = %+v after the update", after)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	store := NewRealtimeStore()
+	_ = store.Register(1, "port", "device", "a")
+	samples, cancel := store.Subscribe(4)
+
+	_ = store.Update(1, func(sample *domain.Sample) {
+		sample.Value = float64(1)
+		sample.Quality = domain.QualityGood
+	})
+	// the same value and quality are not notified again
+	_ = store.Update(1, func(sample *domain.Sample) {
+		sample.Value = float64(1)
+	})
+	_ = store.Update(1, func(sample *domain.Sample) {
+		sample.Quality = domain.QualityUncertainStale
+	})
+	for _, want := range []domain.Quality{domain.QualityGood, domain.QualityUncertainStale} {
+		sample := <-samples
+		if sample.Quality != want || sample.Value != float64(1) {
+			t.Fatalf("notified %s %v, want %s 1", sample.Quality, sample.Value, want)
+		}
+	}
+	select {
+	case sample := <-samples:
+		t.Fatalf("unchanged sample %+v is notified", sample)
+	default:
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-samples; ok {
+		t.Fatal("the channel is not closed by cancel")
+	}
+	_ = store.Update(1, func(sample *domain.Sample) {
+		sample.Value = float64(2)
+	})
+}
+
+func TestSubscriberDoesNotBlock(t *testing.T) {
+	store := NewRealtimeStore()
+	_ = store.Register(1, "port", "device", "a")
+	samples, cancel := store.Subscribe(1)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		value := float64(i)
+		_ = store.Update(1, func(sample *domain.Sample) {
+			sample.Value = value
+		})
+	}
+	if sample := <-samples; sample.Value != float64(0) {
+		t.Fatalf("notified %v, want the first change", sample.Value)
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	store := NewRealtimeStore()
+	_ = store.Register(1, "port", "device", "a")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = store.Update(1, func(sample *domain.Sample) {
+					count, _ := sample.Value.(int)
+					sample.Value = count + 1
+				})
+				store.Get(1)
+				store.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	if sample, _ := store.Get(1); sample.Value != 800 {
+		t.Fatalf("Value = %v after 800 updates, want 800", sample.Value)
+	}
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"didaGatewayCenter/dataPointDriver/connection"
+	"didaGatewayCenter/domain"
+	"errors"
+	"fmt"
+	"github.com/goburrow/serial"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFrameInterval = time.Millisecond * 50
+	maxFrameLength       = 1024
+)
+
+type penetratePort struct {
+	portConfig *domain.DataPointPortConfig
+	port       serial.Port
+	writeLock  sync.Mutex
+}
+
+type serialPenetrateUsecase struct {
+	iLogU        domain.ILogUsecase
+	ports        []*penetratePort
+	listeners    map[string][]domain.FrameListener
+	listenerLock sync.RWMutex
+}
+
+func (s *serialPenetrateUsecase) AddListener(portName string, listener domain.FrameListener) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+	s.listeners[portName] = append(s.listeners[portName], listener)
+}
+
+func (s *serialPenetrateUsecase) Write(portName string, data []byte) error {
+	found := false
+	for _, singlePort := range s.ports {
+		if portName != "" && singlePort.portConfig.PortName != portName {
+			continue
+		}
+		found = true
+		if err := singlePort.write(data); err != nil {
+			return fmt.Errorf("write to %s failed: %w", singlePort.portConfig.PortName, err)
+		}
+	}
+	if !found {
+		return fmt.Errorf("pass-through port %s is not found", portName)
+	}
+	return nil
+}
+
+// receive splits the received bytes into frames, a frame ends when the port is idle for FrameIntervalMs
+func (s *serialPenetrateUsecase) receive(p *penetratePort) {
+	portName := p.portConfig.PortName
+	policy := connection.NewPolicy(p.portConfig.Param.Reconnect)
+	for {
+		p.open(policy, s.iLogU.GetLogger())
+		var frame []byte
+		buffer := make([]byte, maxFrameLength)
+		for {
+			n, err := p.port.Read(buffer)
+			if err != nil && !errors.Is(err, serial.ErrTimeout) {
+				s.iLogU.GetLogger().Warn("read from pass-through serial port failed", zap.String("portName", portName), zap.Error(err))
+				p.close()
+				break
+			}
+			frame = append(frame, buffer[:n]...)
+			if len(frame) == 0 || (err == nil && len(frame) < maxFrameLength) {
+				continue
+			}
+			s.dispatch(portName, frame)
+			frame = nil
+		}
+	}
+}
+
+func (s *serialPenetrateUsecase) dispatch(portName string, frame []byte) {
+	s.iLogU.GetLogger().Debug("pass-through frame received", zap.String("portName", portName),
+		zap.String("frame", fmt.Sprintf("% X", frame)))
+	s.listenerLock.RLock()
+	defer s.listenerLock.RUnlock()
+	for _, listener := range s.listeners[portName] {
+		listener(portName, frame)
+	}
+	for _, listener := range s.listeners[""] {
+		listener(portName, frame)
+	}
+}
+
+// open the port is retried with the backoff of its reconnect policy until it opens
+func (p *penetratePort) open(policy connection.Policy, logger *zap.Logger) {
+	frameInterval := time.Duration(p.portConfig.Param.FrameIntervalMs) * time.Millisecond
+	if frameInterval <= 0 {
+		frameInterval = defaultFrameInterval
+	}
+	// a read times out once the port is idle for a frame interval
+	port := connection.OpenSerial(p.portConfig, frameInterval, policy, logger)
+	p.writeLock.Lock()
+	p.port = port
+	p.writeLock.Unlock()
+}
+
+func (p *penetratePort) close() {
+	p.writeLock.Lock()
+	defer p.writeLock.Unlock()
+	if p.port != nil {
+		_ = p.port.Close()
+		p.port = nil
+	}
+}
+
+func (p *penetratePort) write(data []byte) error {
+	p.writeLock.Lock()
+	defer p.writeLock.Unlock()
+	if p.port == nil {
+		return fmt.Errorf("serial port is not opened")
+	}
+	for len(data) > 0 {
+		n, err := p.port.Write(data)
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func NewSerialPenetrateUsecase(iLogU domain.ILogUsecase, dataPointConfig domain.IDataPointConfigUseCase) domain.ISerialPenetrateUsecase {
+	s := serialPenetrateUsecase{
+		iLogU:     iLogU,
+		listeners: map[string][]domain.FrameListener{},
+	}
+	penetratePorts := dataPointConfig.GetPortConfigsByDeviceType(domain.DeviceTypeSerialPortPenetrate)
+	for index, singlePort := range penetratePorts.PortConfigs {
+		if !singlePort.Vaild {
+			continue
+		}
+		if singlePort.PortType != domain.SerialType {
+			iLogU.GetLogger().Warn("pass-through is only supported on serial ports, skipping", zap.String("port", singlePort.PortName))
+			continue
+		}
+		p := &penetratePort{portConfig: &penetratePorts.PortConfigs[index]}
+		s.ports = append(s.ports, p)
+		go s.receive(p)
+	}
+	return &s
+}